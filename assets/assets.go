@@ -7,3 +7,6 @@ var LobbySettingsJSON []byte
 
 //go:embed geoip.mmdb
 var GeoIPDB []byte
+
+//go:embed autoban.json
+var AutoBanPolicyJSON []byte
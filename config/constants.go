@@ -9,6 +9,7 @@ import (
 	"os"
 	"reflect"
 	"text/template"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/kelseyhightower/envconfig"
@@ -63,6 +64,52 @@ type constants struct {
 	SecureCookies      bool     `envconfig:"SECURE_COOKIE" doc:"Enable 'secure' flag on cookies" default:"false"`
 	FilteredWords      []string `envconfig:"FILTERED_WORDS"`
 	DemosFolder        string   `envconfig:"DEMOS_FOLDER" doc:"Folder to store STV demos in" default:"demos"`
+
+	// STV demos
+	DemosMaxAge time.Duration `envconfig:"DEMOS_MAX_AGE" default:"720h" doc:"Demos older than this are deleted, 0 keeps them forever"`
+	DemosQuota  int64         `envconfig:"DEMOS_QUOTA" default:"10240" doc:"Maximum total size of stored demos in MB, the oldest demos are deleted first. 0 disables the quota"`
+
+	DemosStorage   string        `envconfig:"DEMOS_STORAGE" default:"local" doc:"Where demos are stored, either local (in DEMOS_FOLDER) or s3"`
+	DemosURLExpiry time.Duration `envconfig:"DEMOS_URL_EXPIRY" default:"168h" doc:"Time demo download links are valid for (at most 168h for S3)"`
+	S3Endpoint     string        `envconfig:"S3_ENDPOINT" default:"s3.amazonaws.com" doc:"S3 endpoint (host[:port]) for storing demos"`
+	S3Region       string        `envconfig:"S3_REGION" default:"us-east-1" doc:"S3 region"`
+	S3Bucket       string        `envconfig:"S3_BUCKET" doc:"S3 bucket to store demos in"`
+	S3AccessKey    string        `envconfig:"S3_ACCESS_KEY" doc:"S3 access key ID"`
+	S3SecretKey    string        `envconfig:"S3_SECRET_KEY" doc:"S3 secret access key"`
+	S3Secure       bool          `envconfig:"S3_SECURE" default:"true" doc:"Use HTTPS for S3 requests"`
+	S3PathStyle    bool          `envconfig:"S3_PATH_STYLE" default:"false" doc:"Use path-style S3 URLs (needed for MinIO)"`
+
+	// GeoIP database, used when GEOIP is enabled
+	GeoIPPath   string        `envconfig:"GEOIP_PATH" doc:"Path to the MaxMind GeoLite2 Country database, reloaded when it changes. The database embedded at build time is used when empty"`
+	GeoIPReload time.Duration `envconfig:"GEOIP_RELOAD_INTERVAL" default:"1m" doc:"How often GEOIP_PATH is checked for changes"`
+
+	// serveme.tf reservations of in-progress lobbies
+	ServemeExtendAt     time.Duration `envconfig:"SERVEME_EXTEND_AT" default:"5m" doc:"Extend the serveme reservation of an in-progress lobby when it ends in less than this"`
+	ServemeMaxExtension time.Duration `envconfig:"SERVEME_MAX_EXTENSION" default:"1h" doc:"Maximum total time a lobby's serveme reservation can be extended by, 0 disables extending. Can be changed in /admin/server/"`
+
+	// login sessions
+	JWTExpiry       time.Duration `envconfig:"JWT_EXPIRY" default:"15m" doc:"Time access tokens (the auth-jwt cookie) are valid for, they're refreshed with the session's refresh token"`
+	SessionLifetime time.Duration `envconfig:"SESSION_LIFETIME" default:"720h" doc:"Time players stay logged in for"`
+
+	// public HTTP API
+	APIRateLimit int `envconfig:"API_RATE_LIMIT" default:"60" doc:"Maximum number of requests per minute to /api/v1/ from a single IP address, 0 disables the limit"`
+
+	// automatic bans for reports
+	AutoBanPolicy string `envconfig:"AUTOBAN_POLICY" doc:"Path to a JSON file with the policy for automatic bans (see assets/autoban.json). The policy embedded at build time is used when empty"`
+
+	// linking alt accounts by hashed IP address
+	IPRetention     time.Duration `envconfig:"IP_RETENTION" default:"720h" doc:"Time hashed login addresses are kept for linking accounts, 0 keeps them forever"`
+	AltAccountAge   time.Duration `envconfig:"ALT_ACCOUNT_AGE" default:"168h" doc:"Accounts younger than this (and banned accounts) are flagged when they share addresses with a banned account"`
+	AltBanConfirmed bool          `envconfig:"ALT_BAN_CONFIRMED" default:"false" doc:"Give accounts confirmed as alts by mods the active bans of the account they're linked to"`
+
+	// chat rate limiting, per player across all their sockets
+	ChatBurst           int           `envconfig:"CHAT_BURST" default:"8" doc:"Number of chat messages a player can send at once across all rooms, 0 disables the limit"`
+	ChatRate            time.Duration `envconfig:"CHAT_RATE" default:"1s" doc:"Time it takes for a player to be able to send another message after using up CHAT_BURST"`
+	ChatRoomBurst       int           `envconfig:"CHAT_ROOM_BURST" default:"5" doc:"Number of chat messages a player can send at once in a single room, 0 disables the limit"`
+	ChatRoomRate        time.Duration `envconfig:"CHAT_ROOM_RATE" default:"2s" doc:"Time it takes for a player to be able to send another message in a room after using up CHAT_ROOM_BURST"`
+	ChatDuplicateWindow time.Duration `envconfig:"CHAT_DUPLICATE_WINDOW" default:"30s" doc:"Players can't repeat one of their last 3 messages within this time, 0 disables duplicate detection"`
+	ChatCooldown        time.Duration `envconfig:"CHAT_COOLDOWN" default:"10s" doc:"Time players can't chat for after repeatedly going over the chat limits, doubled on every further violation"`
+	ChatCooldownMax     time.Duration `envconfig:"CHAT_COOLDOWN_MAX" default:"10m" doc:"Maximum chat cooldown"`
 }
 
 var Constants = constants{}
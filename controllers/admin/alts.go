@@ -0,0 +1,128 @@
+package admin
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	"github.com/TF2Stadium/Helen/config"
+	chelpers "github.com/TF2Stadium/Helen/controllers/controllerhelpers"
+	"github.com/TF2Stadium/Helen/models"
+	"github.com/TF2Stadium/Helen/models/player"
+	"golang.org/x/net/xsrftoken"
+)
+
+var altsTempl *template.Template
+
+type linkedAccount struct {
+	Player *player.Player
+	Shared int
+	Bans   []*player.PlayerBan
+}
+
+type altRow struct {
+	*player.AltFlag
+	Alt    linkedAccount
+	Linked linkedAccount
+}
+
+func getLinkedAccount(id uint, shared int) linkedAccount {
+	p := getPlayerOrUnknown(id)
+	var bans []*player.PlayerBan
+	if p.ID != 0 {
+		bans, _ = p.GetActiveBans()
+	}
+	return linkedAccount{p, shared, bans}
+}
+
+//ViewAlts shows flagged alt accounts, or the accounts linked to
+//the player with the given steamid
+func ViewAlts(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	data := map[string]interface{}{
+		"XSRFToken":       xsrftoken.Generate(config.Constants.CookieStoreSecret, "admin", "POST"),
+		"AltBanConfirmed": config.Constants.AltBanConfirmed,
+	}
+
+	if steamid := values.Get("steamid"); steamid != "" {
+		p, err := player.GetPlayerBySteamID(steamid)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		var linked []linkedAccount
+		for id, shared := range p.GetLinkedAccounts() {
+			linked = append(linked, getLinkedAccount(id, shared))
+		}
+		data["Player"] = p
+		data["Linked"] = linked
+	} else {
+		status := player.AltStatus(values.Get("status"))
+		if status == "" {
+			status = player.AltOpen
+		}
+
+		var rows []altRow
+		for _, flag := range player.GetAltFlags(status) {
+			rows = append(rows, altRow{
+				AltFlag: flag,
+				Alt:     getLinkedAccount(flag.PlayerID, flag.Shared),
+				Linked:  getLinkedAccount(flag.LinkedID, flag.Shared),
+			})
+		}
+		data["Flags"] = rows
+	}
+
+	err := altsTempl.Execute(w, data)
+	if err != nil {
+		logrus.Error(err)
+	}
+}
+
+func ReviewAlt(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	values := r.Form
+
+	token := values.Get("xsrf-token")
+	if !xsrftoken.Valid(token, config.Constants.CookieStoreSecret, "admin", "POST") {
+		http.Error(w, "invalid xsrf token", http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.ParseUint(values.Get("id"), 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid flag ID", http.StatusBadRequest)
+		return
+	}
+	flag, err := player.GetAltFlag(uint(id))
+	if err != nil {
+		http.Error(w, "Flag not found", http.StatusNotFound)
+		return
+	}
+
+	jwt, _ := chelpers.GetToken(r)
+	mod := chelpers.GetPlayer(jwt)
+	alt := getPlayerOrUnknown(flag.PlayerID)
+	linked := getPlayerOrUnknown(flag.LinkedID)
+
+	if values.Get("confirm") == "true" {
+		err = flag.Confirm(mod.ID, config.Constants.AltBanConfirmed)
+		if err == nil {
+			chelpers.AuditHTTP(r, models.AuditConfirmAlt, alt.SteamID, "", "alt of "+linked.SteamID)
+		}
+	} else {
+		err = flag.Dismiss(mod.ID)
+		if err == nil {
+			chelpers.AuditHTTP(r, models.AuditDismissAlt, alt.SteamID, "", "not an alt of "+linked.SteamID)
+		}
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	fmt.Fprintf(w, "Flag #%d %s.", flag.ID, flag.Status)
+}
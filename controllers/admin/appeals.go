@@ -0,0 +1,164 @@
+package admin
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/TF2Stadium/Helen/config"
+	chelpers "github.com/TF2Stadium/Helen/controllers/controllerhelpers"
+	db "github.com/TF2Stadium/Helen/database"
+	"github.com/TF2Stadium/Helen/models"
+	"github.com/TF2Stadium/Helen/models/chat"
+	"github.com/TF2Stadium/Helen/models/lobby/timeline"
+	"github.com/TF2Stadium/Helen/models/notification"
+	"github.com/TF2Stadium/Helen/models/player"
+	"golang.org/x/net/xsrftoken"
+)
+
+var (
+	appealsTempl *template.Template
+	appealTempl  *template.Template
+)
+
+type appealRow struct {
+	*player.BanAppeal
+	Ban *player.PlayerBan
+}
+
+func ViewAppeals(w http.ResponseWriter, r *http.Request) {
+	status := player.AppealStatus(r.URL.Query().Get("status"))
+	if status == "" {
+		status = player.AppealPending
+	}
+
+	var rows []appealRow
+	for _, appeal := range player.GetAppeals(status) {
+		ban, err := appeal.GetBan()
+		if err != nil {
+			logrus.Error(err)
+			continue
+		}
+		rows = append(rows, appealRow{appeal, ban})
+	}
+
+	err := appealsTempl.Execute(w, map[string]interface{}{
+		"Appeals": rows,
+		"Status":  status,
+	})
+	if err != nil {
+		logrus.Error(err)
+	}
+}
+
+//ViewAppeal shows an appeal along with what led to the ban: reports
+//against the player, and their lobby events and chat messages before it
+func ViewAppeal(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(r.URL.Query().Get("id"), 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid appeal ID", http.StatusBadRequest)
+		return
+	}
+
+	appeal, err := player.GetAppeal(uint(id))
+	if err != nil {
+		http.Error(w, "Appeal not found", http.StatusNotFound)
+		return
+	}
+	ban, err := appeal.GetBan()
+	if err != nil {
+		http.Error(w, "Ban not found", http.StatusNotFound)
+		return
+	}
+
+	var messages []*chat.ChatMessage
+	db.DB.Where("player_id = ? AND created_at <= ?", ban.PlayerID, ban.CreatedAt).Order("id desc").Limit(30).Find(&messages)
+
+	err = appealTempl.Execute(w, map[string]interface{}{
+		"XSRFToken":   xsrftoken.Generate(config.Constants.CookieStoreSecret, "admin", "POST"),
+		"Appeal":      appeal,
+		"Ban":         ban,
+		"Reports":     ban.Player.GetReportsAgainst(),
+		"Events":      timeline.GetPlayerEvents(ban.PlayerID, ban.CreatedAt, 30),
+		"Messages":    messages,
+		"FrontendURL": config.Constants.LoginRedirectPath,
+	})
+	if err != nil {
+		logrus.Error(err)
+	}
+}
+
+func DecideAppeal(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	values := r.Form
+
+	token := values.Get("xsrf-token")
+	if !xsrftoken.Valid(token, config.Constants.CookieStoreSecret, "admin", "POST") {
+		http.Error(w, "invalid xsrf token", http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.ParseUint(values.Get("id"), 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid appeal ID", http.StatusBadRequest)
+		return
+	}
+	appeal, err := player.GetAppeal(uint(id))
+	if err != nil {
+		http.Error(w, "Appeal not found", http.StatusNotFound)
+		return
+	}
+	ban, err := appeal.GetBan()
+	if err != nil {
+		http.Error(w, "Ban not found", http.StatusNotFound)
+		return
+	}
+
+	jwt, _ := chelpers.GetToken(r)
+	mod := chelpers.GetPlayer(jwt)
+	response := values.Get("response")
+	target := strconv.FormatUint(uint64(ban.ID), 10)
+	before := ban.Until.Format(time.RFC822)
+
+	switch values.Get("decision") {
+	case "accept":
+		err = appeal.Accept(mod.ID, response)
+		if err == nil {
+			chelpers.AuditHTTP(r, models.AuditAcceptAppeal, target, before, response)
+		}
+	case "reduce":
+		var until time.Time
+		until, err = time.Parse("2006-01-02 15:04", values.Get("date")+" "+values.Get("time"))
+		if err != nil {
+			http.Error(w, "invalid time format", http.StatusBadRequest)
+			return
+		}
+		err = appeal.Reduce(mod.ID, until, response)
+		if err == nil {
+			chelpers.AuditHTTP(r, models.AuditReduceAppeal, target, before, until.Format(time.RFC822)+": "+response)
+		}
+	case "reject":
+		err = appeal.Reject(mod.ID, response)
+		if err == nil {
+			chelpers.AuditHTTP(r, models.AuditRejectAppeal, target, before, response)
+		}
+	default:
+		http.Error(w, "Invalid decision", http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	message := fmt.Sprintf("Your appeal of your %s was %s", ban.Type.String(), appeal.Status)
+	if appeal.Response != "" {
+		message += ": " + appeal.Response
+	}
+	notification.Send(ban.Player.ID, ban.Player.SteamID, notification.Appeal, message, appeal)
+	fmt.Fprintf(w, "Appeal #%d %s.", appeal.ID, appeal.Status)
+}
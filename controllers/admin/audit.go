@@ -0,0 +1,116 @@
+// Copyright (C) 2015  TF2Stadium
+// Use of this source code is governed by the GPLv3
+// that can be found in the COPYING file.
+
+package admin
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/TF2Stadium/Helen/models"
+	"github.com/TF2Stadium/Helen/models/player"
+)
+
+var auditTempl *template.Template
+
+//number of entries shown on each page of the audit log
+const auditPageSize = 50
+
+type auditRow struct {
+	*models.AdminLogEntry
+	Actor *player.Player
+}
+
+//getAuditFilter reads the filter from the query string
+func getAuditFilter(values url.Values) (models.AuditFilter, error) {
+	filter := models.AuditFilter{
+		Action: values.Get("action"),
+		Target: values.Get("target"),
+	}
+
+	var err error
+	filter.PlayerID, filter.Since, filter.Until, err = getPlayerRange(values)
+	return filter, err
+}
+
+//getActors returns the player who did each entry, players are only fetched once
+func getActors(entries []*models.AdminLogEntry) []auditRow {
+	players := make(map[uint]*player.Player)
+	rows := make([]auditRow, len(entries))
+
+	for i, entry := range entries {
+		p, ok := players[entry.PlayerID]
+		if !ok {
+			p, _ = player.GetPlayerByID(entry.PlayerID)
+			if p == nil {
+				p = &player.Player{Name: "(unknown)"}
+			}
+			players[entry.PlayerID] = p
+		}
+		rows[i] = auditRow{entry, p}
+	}
+
+	return rows
+}
+
+func ViewAuditLog(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	filter, err := getAuditFilter(values)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if values.Get("format") == "csv" {
+		entries, _ := models.GetAuditLog(filter, 0, 0)
+		writeAuditCSV(w, getActors(entries))
+		return
+	}
+
+	page := getPage(values)
+	entries, total := models.GetAuditLog(filter, (page-1)*auditPageSize, auditPageSize)
+
+	data := map[string]interface{}{
+		"Entries": getActors(entries),
+		"Filter":  values,
+		"Actions": models.AuditActions,
+	}
+	// the query string is also used for the CSV export
+	setPages(data, values, page, auditPageSize, total)
+
+	err = auditTempl.Execute(w, data)
+	if err != nil {
+		logrus.Error(err)
+	}
+}
+
+func writeAuditCSV(w http.ResponseWriter, rows []auditRow) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="audit-%s.csv"`, time.Now().Format("2006-01-02")))
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"time", "steamid", "name", "action", "target", "before", "after", "ip"})
+	for _, row := range rows {
+		writer.Write([]string{
+			row.CreatedAt.UTC().Format(time.RFC3339),
+			row.Actor.SteamID,
+			row.Actor.Name,
+			row.RelText,
+			row.Target,
+			row.Before,
+			row.After,
+			row.IPAddr,
+		})
+	}
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		logrus.Error(err)
+	}
+}
@@ -8,17 +8,48 @@ import (
 	"fmt"
 	"html/template"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/TF2Stadium/Helen/config"
 	chelpers "github.com/TF2Stadium/Helen/controllers/controllerhelpers"
+	"github.com/TF2Stadium/Helen/helpers"
+	"github.com/TF2Stadium/Helen/helpers/authority"
+	"github.com/TF2Stadium/Helen/models"
 	"github.com/TF2Stadium/Helen/models/player"
 	"golang.org/x/net/xsrftoken"
 )
 
 var banlogsTempl *template.Template
 
+var banTypes = map[string]player.BanType{
+	"joinLobby":       player.BanJoin,
+	"joinMumbleLobby": player.BanJoinMumble,
+	"createLobby":     player.BanCreate,
+	"chat":            player.BanChat,
+	"full":            player.BanFull,
+}
+
+//banActions are the actions needed to give (or remove) each type of ban
+var banActions = map[player.BanType][]authority.AuthAction{
+	player.BanJoin:       {helpers.ActionBanJoin},
+	player.BanJoinMumble: {helpers.ActionBanJoin},
+	player.BanCreate:     {helpers.ActionBanCreate},
+	player.BanChat:       {helpers.ActionBanChat},
+	player.BanFull:       {helpers.ActionBanJoin, helpers.ActionBanCreate, helpers.ActionBanChat},
+}
+
+//canBan returns whether mod can give bans of the given type
+func canBan(mod *player.Player, ban player.BanType) bool {
+	for _, action := range banActions[ban] {
+		if !mod.Role.Can(action) {
+			return false
+		}
+	}
+	return true
+}
+
 func BanPlayer(w http.ResponseWriter, r *http.Request) {
 	err := r.ParseForm()
 	if err != nil {
@@ -36,18 +67,19 @@ func BanPlayer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ban, ok := map[string]player.BanType{
-		"joinLobby":       player.BanJoin,
-		"joinMumbleLobby": player.BanJoinMumble,
-		"createLobby":     player.BanCreate,
-		"chat":            player.BanChat,
-		"full":            player.BanFull,
-	}[banType]
+	ban, ok := banTypes[banType]
 	if !ok {
 		http.Error(w, "Invalid ban type", http.StatusBadRequest)
 		return
 	}
 
+	jwt, _ := chelpers.GetToken(r)
+	bannedByPlayer := chelpers.GetPlayer(jwt)
+	if !canBan(bannedByPlayer, ban) {
+		http.Error(w, "You aren't authorized to give this type of ban", http.StatusForbidden)
+		return
+	}
+
 	player, err := player.GetPlayerBySteamID(steamid)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -59,6 +91,7 @@ func BanPlayer(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 		} else {
+			chelpers.AuditHTTP(r, models.AuditUnban, player.SteamID, ban.String(), "")
 			fmt.Fprintf(w, "Player %s (%s) has been unbanned (%s)", player.Name, player.SteamID, ban.String())
 		}
 		return
@@ -73,18 +106,48 @@ func BanPlayer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jwt, _ := chelpers.GetToken(r)
-	bannedByPlayer := chelpers.GetPlayer(jwt)
-
 	err = player.BanUntil(until, ban, reason, bannedByPlayer.ID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	chelpers.AuditHTTP(r, models.AuditBan, player.SteamID, "", fmt.Sprintf("%s till %s: %s", ban.String(), until.Format(time.RFC822), reason))
 
 	fmt.Fprintf(w, "Player %s (%s) has been banned (%s) till %v", player.Name, player.SteamID, ban.String(), until)
 }
 
+//SetAutoBanExempt exempts a player from (or makes them subject to) automatic bans for reports
+func SetAutoBanExempt(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	values := r.Form
+	if !xsrftoken.Valid(values.Get("xsrf-token"), config.Constants.CookieStoreSecret, "admin", "POST") {
+		http.Error(w, "invalid xsrf token", http.StatusBadRequest)
+		return
+	}
+
+	player, err := player.GetPlayerBySteamID(values.Get("steamid"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	before := strconv.FormatBool(player.AutoBanExempt)
+	player.AutoBanExempt = values.Get("remove") != "true"
+	player.Save()
+	chelpers.AuditHTTP(r, models.AuditAutoBanExempt, player.SteamID, before, strconv.FormatBool(player.AutoBanExempt))
+
+	if player.AutoBanExempt {
+		fmt.Fprintf(w, "Player %s (%s) is now exempt from automatic bans", player.Name, player.SteamID)
+	} else {
+		fmt.Fprintf(w, "Player %s (%s) can be banned automatically again", player.Name, player.SteamID)
+	}
+}
+
 func GetBanLogs(w http.ResponseWriter, r *http.Request) {
 	values := r.URL.Query()
 	if !xsrftoken.Valid(values.Get("xsrf-token"), config.Constants.CookieStoreSecret, "admin", "POST") {
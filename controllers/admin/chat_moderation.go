@@ -0,0 +1,169 @@
+package admin
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/TF2Stadium/Helen/config"
+	chelpers "github.com/TF2Stadium/Helen/controllers/controllerhelpers"
+	"github.com/TF2Stadium/Helen/models"
+	"github.com/TF2Stadium/Helen/models/chat"
+	"github.com/TF2Stadium/Helen/models/player"
+	"golang.org/x/net/xsrftoken"
+)
+
+var chatModTempl *template.Template
+
+type muteEntry struct {
+	*chat.Mute
+	Player  *player.Player
+	MutedBy *player.Player
+}
+
+func ViewChatModeration(w http.ResponseWriter, r *http.Request) {
+	var mutes []muteEntry
+	for _, mute := range chat.GetActiveMutes() {
+		entry := muteEntry{Mute: mute, Player: getPlayerOrUnknown(mute.PlayerID)}
+		if mute.MutedBy != 0 {
+			entry.MutedBy = getPlayerOrUnknown(mute.MutedBy)
+		}
+		mutes = append(mutes, entry)
+	}
+
+	err := chatModTempl.Execute(w, map[string]interface{}{
+		"XSRFToken": xsrftoken.Generate(config.Constants.CookieStoreSecret, "admin", "POST"),
+		"Filters":   chat.GetFilters(),
+		"SlowModes": chat.GetSlowModes(),
+		"Mutes":     mutes,
+	})
+	if err != nil {
+		logrus.Error(err)
+	}
+}
+
+func AddChatFilter(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	values := r.Form
+
+	token := values.Get("xsrf-token")
+	if !xsrftoken.Valid(token, config.Constants.CookieStoreSecret, "admin", "POST") {
+		http.Error(w, "invalid xsrf token", http.StatusBadRequest)
+		return
+	}
+
+	muteFor, _ := strconv.Atoi(values.Get("mutefor"))
+	action := chat.FilterAction(values.Get("action"))
+	err := chat.AddFilter(values.Get("pattern"), action, muteFor, values.Get("note"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	chelpers.AuditHTTP(r, models.AuditAddFilter, values.Get("pattern"), "", string(action))
+
+	fmt.Fprintf(w, "Filter successfully added.")
+}
+
+func RemoveChatFilter(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	values := r.Form
+
+	token := values.Get("xsrf-token")
+	if !xsrftoken.Valid(token, config.Constants.CookieStoreSecret, "admin", "POST") {
+		http.Error(w, "invalid xsrf token", http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.ParseUint(values.Get("id"), 10, 32)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	chat.RemoveFilter(uint(id))
+	chelpers.AuditHTTP(r, models.AuditRemoveFilter, values.Get("id"), "", "")
+	fmt.Fprintf(w, "Filter successfully removed.")
+}
+
+func SetSlowMode(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	values := r.Form
+
+	token := values.Get("xsrf-token")
+	if !xsrftoken.Valid(token, config.Constants.CookieStoreSecret, "admin", "POST") {
+		http.Error(w, "invalid xsrf token", http.StatusBadRequest)
+		return
+	}
+
+	room, err := strconv.Atoi(values.Get("room"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	interval, err := strconv.Atoi(values.Get("interval"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	before := chat.GetSlowMode(room)
+	if err := chat.SetSlowMode(room, time.Duration(interval)*time.Second); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	chelpers.AuditHTTP(r, models.AuditSlowMode, values.Get("room"), before.String(), chat.GetSlowMode(room).String())
+
+	fmt.Fprintf(w, "Slow mode successfully updated.")
+}
+
+func MuteChat(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	values := r.Form
+
+	token := values.Get("xsrf-token")
+	if !xsrftoken.Valid(token, config.Constants.CookieStoreSecret, "admin", "POST") {
+		http.Error(w, "invalid xsrf token", http.StatusBadRequest)
+		return
+	}
+
+	room, err := strconv.Atoi(values.Get("room"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	target, err := player.GetPlayerBySteamID(values.Get("steamid"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if values.Get("unmute") == "on" {
+		if err := chat.UnmutePlayer(target.ID, room); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		chelpers.AuditHTTP(r, models.AuditUnmute, target.SteamID, "", fmt.Sprintf("room %d", room))
+		fmt.Fprintf(w, "Player successfully unmuted.")
+		return
+	}
+
+	until, err := time.Parse("2006-01-02 15:04", values.Get("date")+" "+values.Get("time"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jwt, _ := chelpers.GetToken(r)
+	mod := chelpers.GetPlayer(jwt)
+	reason := values.Get("reason")
+	if err := chat.MutePlayer(target.ID, room, until, reason, mod.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	chelpers.AuditHTTP(r, models.AuditMute, target.SteamID, "", fmt.Sprintf("room %d till %s: %s", room, until.Format(time.RFC822), reason))
+
+	fmt.Fprintf(w, "Player successfully muted.")
+}
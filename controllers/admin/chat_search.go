@@ -0,0 +1,83 @@
+package admin
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	"github.com/TF2Stadium/Helen/models/chat"
+)
+
+const searchPageSize = 50
+
+var (
+	chatSearchTempl  *template.Template
+	chatContextTempl *template.Template
+)
+
+func getSearchFilter(values url.Values) (chat.SearchFilter, error) {
+	filter := chat.SearchFilter{Query: values.Get("q"), Room: -1}
+
+	var err error
+	if values.Get("room") != "" {
+		filter.Room, err = strconv.Atoi(values.Get("room"))
+		if err != nil {
+			return filter, err
+		}
+	}
+
+	filter.PlayerID, filter.Since, filter.Until, err = getPlayerRange(values)
+	return filter, err
+}
+
+func SearchChat(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	data := map[string]interface{}{"Filter": values}
+
+	if values.Get("q") != "" {
+		filter, err := getSearchFilter(values)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		page := getPage(values)
+		results, total, err := chat.Search(filter, (page-1)*searchPageSize, searchPageSize)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		data["Results"] = results
+		setPages(data, values, page, searchPageSize, total)
+	}
+
+	err := chatSearchTempl.Execute(w, data)
+	if err != nil {
+		logrus.Error(err)
+	}
+}
+
+func ViewChatContext(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(r.URL.Query().Get("id"), 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return
+	}
+
+	messages, err := chat.GetContext(uint(id), 25)
+	if err != nil {
+		http.Error(w, "Message not found", http.StatusNotFound)
+		return
+	}
+
+	err = chatContextTempl.Execute(w, map[string]interface{}{
+		"ID":       uint(id),
+		"Messages": messages,
+	})
+	if err != nil {
+		logrus.Error(err)
+	}
+}
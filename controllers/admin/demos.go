@@ -0,0 +1,70 @@
+package admin
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	"github.com/TF2Stadium/Helen/config"
+	chelpers "github.com/TF2Stadium/Helen/controllers/controllerhelpers"
+	"github.com/TF2Stadium/Helen/models"
+	"github.com/TF2Stadium/Helen/models/demo"
+	"golang.org/x/net/xsrftoken"
+)
+
+var demosTempl *template.Template
+
+const demosPerPage = 50
+
+func ViewDemos(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 0 {
+		page = 0
+	}
+
+	err := demosTempl.Execute(w, map[string]interface{}{
+		"Demos":     demo.GetAllDemos(page*demosPerPage, demosPerPage),
+		"TotalSize": demo.TotalSize() / (1024 * 1024),
+		"Quota":     config.Constants.DemosQuota,
+		"MaxAge":    config.Constants.DemosMaxAge,
+		"Page":      page,
+		"NextPage":  page + 1,
+		"XSRFToken": xsrftoken.Generate(config.Constants.CookieStoreSecret, "admin", "POST"),
+	})
+	if err != nil {
+		logrus.Error(err)
+	}
+}
+
+func DeleteDemo(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	values := r.Form
+
+	token := values.Get("xsrf-token")
+	if !xsrftoken.Valid(token, config.Constants.CookieStoreSecret, "admin", "POST") {
+		http.Error(w, "invalid xsrf token", http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.ParseUint(values.Get("id"), 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid demo ID", http.StatusBadRequest)
+		return
+	}
+
+	d, err := demo.GetDemo(uint(id))
+	if err != nil {
+		http.Error(w, "Demo not found", http.StatusNotFound)
+		return
+	}
+
+	if err := d.Delete(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	chelpers.AuditHTTP(r, models.AuditDeleteDemo, strconv.FormatUint(uint64(d.ID), 10), "", "")
+
+	fmt.Fprintf(w, "Demo #%d successfully deleted.", d.ID)
+}
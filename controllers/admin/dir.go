@@ -10,6 +10,8 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/TF2Stadium/Helen/config"
+	"github.com/TF2Stadium/Helen/helpers"
+	"github.com/TF2Stadium/Helen/models/role"
 	"golang.org/x/net/xsrftoken"
 )
 
@@ -21,18 +23,16 @@ var banForm = map[string]string{
 	"full":            "Full ban",
 }
 
-var roleForm = map[string]string{
-	"admin": "Add Administrator",
-	"mod":   "Add Moderator",
-}
-
 var adminPageTempl *template.Template
 
 func ServeAdminPage(w http.ResponseWriter, r *http.Request) {
 	err := adminPageTempl.Execute(w, map[string]interface{}{
 		"BanForms":  banForm,
-		"RoleForms": roleForm,
+		"Roles":     role.GetAllRoles(),
 		"XSRFToken": xsrftoken.Generate(config.Constants.CookieStoreSecret, "admin", "POST"),
+
+		"GeoIPSource":    helpers.GeoIPSource(),
+		"GeoIPBuildDate": helpers.GeoIPBuildDate(),
 	})
 	if err != nil {
 		logrus.Error(err)
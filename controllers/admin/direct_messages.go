@@ -0,0 +1,73 @@
+package admin
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"github.com/TF2Stadium/Helen/config"
+	chelpers "github.com/TF2Stadium/Helen/controllers/controllerhelpers"
+	"github.com/TF2Stadium/Helen/models"
+	"github.com/TF2Stadium/Helen/models/chat"
+	"github.com/TF2Stadium/Helen/models/player"
+	"golang.org/x/net/xsrftoken"
+)
+
+var directTempl *template.Template
+
+//ViewDirectMessages shows a player's conversations, or their messages with
+//another player. Direct messages are private, so they're only shown for
+//POST requests with a reason, and every access is recorded in the audit log.
+func ViewDirectMessages(w http.ResponseWriter, r *http.Request) {
+	data := map[string]interface{}{
+		"XSRFToken": xsrftoken.Generate(config.Constants.CookieStoreSecret, "admin", "POST"),
+	}
+
+	if r.Method == "POST" {
+		r.ParseForm()
+		values := r.Form
+
+		token := values.Get("xsrf-token")
+		if !xsrftoken.Valid(token, config.Constants.CookieStoreSecret, "admin", "POST") {
+			http.Error(w, "invalid xsrf token", http.StatusBadRequest)
+			return
+		}
+
+		reason := values.Get("reason")
+		if reason == "" {
+			http.Error(w, "A reason is needed to read direct messages", http.StatusBadRequest)
+			return
+		}
+
+		p, err := player.GetPlayerBySteamID(values.Get("steamid"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		data["Player"] = p
+		data["Reason"] = reason
+
+		target := p.SteamID
+		if with := values.Get("with"); with != "" {
+			other, err := player.GetPlayerBySteamID(with)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+
+			target = fmt.Sprintf("%s,%s", p.SteamID, other.SteamID)
+			data["Other"] = other
+			data["Messages"] = chat.GetConversation(p.ID, other.ID, 0, 500)
+		} else {
+			data["Conversations"] = chat.GetConversations(p.ID)
+		}
+
+		chelpers.AuditHTTP(r, models.AuditReadDirect, target, "", reason)
+	}
+
+	err := directTempl.Execute(w, data)
+	if err != nil {
+		logrus.Error(err)
+	}
+}
@@ -0,0 +1,62 @@
+package admin
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+//getPlayerRange reads the player (steamid) and the date range (from and to,
+//as 2006-01-02, both inclusive) the admin search pages filter by
+func getPlayerRange(values url.Values) (playerID uint, since, until time.Time, err error) {
+	get := values.Get
+
+	if steamID := get("steamid"); steamID != "" {
+		playerID = getPlayerID(steamID)
+		if playerID == 0 {
+			err = fmt.Errorf("Couldn't find player with Steam ID %s", steamID)
+			return
+		}
+	}
+
+	if get("from") != "" {
+		since, err = time.Parse("2006-01-02", get("from"))
+		if err != nil {
+			return
+		}
+	}
+	if get("to") != "" {
+		until, err = time.Parse("2006-01-02", get("to"))
+		if err != nil {
+			return
+		}
+		// include the whole day
+		until = until.AddDate(0, 0, 1)
+	}
+
+	return
+}
+
+//getPage returns the page number in the query string, starting at 1
+func getPage(values url.Values) int {
+	page, _ := strconv.Atoi(values.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	return page
+}
+
+//setPages adds what the templates need for the links to the other pages to data.
+//values is changed to the query string for the other pages, without the page.
+func setPages(data map[string]interface{}, values url.Values, page, pageSize, total int) {
+	values.Del("page")
+	values.Del("format")
+
+	data["Total"] = total
+	data["Page"] = page
+	data["Prev"] = page - 1
+	data["Next"] = page + 1
+	data["HasNext"] = page*pageSize < total
+	data["Query"] = values.Encode()
+}
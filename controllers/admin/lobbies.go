@@ -3,14 +3,19 @@ package admin
 import (
 	"html/template"
 	"net/http"
+	"strconv"
 
 	"github.com/sirupsen/logrus"
 	"github.com/TF2Stadium/Helen/config"
 	db "github.com/TF2Stadium/Helen/database"
 	"github.com/TF2Stadium/Helen/models/lobby"
+	"github.com/TF2Stadium/Helen/models/lobby/timeline"
 )
 
-var lobbiesTempl *template.Template
+var (
+	lobbiesTempl  *template.Template
+	timelineTempl *template.Template
+)
 
 func ViewOpenLobbies(w http.ResponseWriter, r *http.Request) {
 	var lobbies []*lobby.Lobby
@@ -23,3 +28,26 @@ func ViewOpenLobbies(w http.ResponseWriter, r *http.Request) {
 		logrus.Error(err)
 	}
 }
+
+func ViewLobbyTimeline(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(r.URL.Query().Get("id"), 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid lobby ID", http.StatusBadRequest)
+		return
+	}
+
+	lob, err := lobby.GetLobbyByIDServer(uint(id))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	err = timelineTempl.Execute(w, map[string]interface{}{
+		"Lobby":       lob,
+		"Events":      timeline.Get(lob.ID),
+		"FrontendURL": config.Constants.LoginRedirectPath,
+	})
+	if err != nil {
+		logrus.Error(err)
+	}
+}
@@ -0,0 +1,88 @@
+package admin
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"github.com/TF2Stadium/Helen/config"
+	chelpers "github.com/TF2Stadium/Helen/controllers/controllerhelpers"
+	"github.com/TF2Stadium/Helen/models"
+	"github.com/TF2Stadium/Helen/models/region"
+	"golang.org/x/net/xsrftoken"
+)
+
+var regionsTempl *template.Template
+
+func ViewRegions(w http.ResponseWriter, r *http.Request) {
+	err := regionsTempl.Execute(w, map[string]interface{}{
+		"XSRFToken": xsrftoken.Generate(config.Constants.CookieStoreSecret, "admin", "POST"),
+		"Regions":   region.GetAllRegions(),
+		"Overrides": region.GetAllOverrides(),
+	})
+	if err != nil {
+		logrus.Error(err)
+	}
+}
+
+func AddRegion(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	values := r.Form
+
+	token := values.Get("xsrf-token")
+	if !xsrftoken.Valid(token, config.Constants.CookieStoreSecret, "admin", "POST") {
+		http.Error(w, "invalid xsrf token", http.StatusBadRequest)
+		return
+	}
+
+	err := region.AddRegion(values.Get("code"), values.Get("name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	chelpers.AuditHTTP(r, models.AuditAddRegion, values.Get("code"), "", values.Get("name"))
+
+	fmt.Fprintf(w, "Region successfully added.")
+}
+
+func AddRegionOverride(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	values := r.Form
+
+	token := values.Get("xsrf-token")
+	if !xsrftoken.Valid(token, config.Constants.CookieStoreSecret, "admin", "POST") {
+		http.Error(w, "invalid xsrf token", http.StatusBadRequest)
+		return
+	}
+
+	err := region.AddOverride(values.Get("cidr"), values.Get("region"), values.Get("note"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	chelpers.AuditHTTP(r, models.AuditAddOverride, values.Get("cidr"), "", values.Get("region"))
+
+	fmt.Fprintf(w, "Override successfully added.")
+}
+
+func RemoveRegionOverride(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	values := r.Form
+
+	token := values.Get("xsrf-token")
+	if !xsrftoken.Valid(token, config.Constants.CookieStoreSecret, "admin", "POST") {
+		http.Error(w, "invalid xsrf token", http.StatusBadRequest)
+		return
+	}
+
+	cidr := values.Get("cidr")
+	if cidr == "" {
+		http.Error(w, "Empty CIDR not allowed", http.StatusBadRequest)
+		return
+	}
+
+	region.RemoveOverride(cidr)
+	chelpers.AuditHTTP(r, models.AuditRemoveOverride, cidr, "", "")
+	fmt.Fprintf(w, "Override successfully removed.")
+}
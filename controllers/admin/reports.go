@@ -0,0 +1,197 @@
+package admin
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/TF2Stadium/Helen/config"
+	chelpers "github.com/TF2Stadium/Helen/controllers/controllerhelpers"
+	db "github.com/TF2Stadium/Helen/database"
+	"github.com/TF2Stadium/Helen/models"
+	"github.com/TF2Stadium/Helen/models/chat"
+	"github.com/TF2Stadium/Helen/models/notification"
+	"github.com/TF2Stadium/Helen/models/player"
+	"golang.org/x/net/xsrftoken"
+)
+
+var reportsTempl *template.Template
+
+type reportRow struct {
+	*player.Report
+	Reporter  *player.Player
+	Reported  *player.Player
+	ClaimedBy *player.Player
+	Messages  []*chat.ChatMessage
+}
+
+func getPlayerOrUnknown(id uint) *player.Player {
+	p, err := player.GetPlayerByID(id)
+	if err != nil {
+		return &player.Player{Name: "(unknown)"}
+	}
+	return p
+}
+
+func ViewReports(w http.ResponseWriter, r *http.Request) {
+	reports := player.GetFiledReports(player.ReportStatus(r.URL.Query().Get("status")))
+
+	rows := make([]reportRow, len(reports))
+	for i, report := range reports {
+		rows[i] = reportRow{
+			Report:   report,
+			Reporter: getPlayerOrUnknown(report.ReporterID),
+			Reported: getPlayerOrUnknown(report.PlayerID),
+		}
+		if report.ClaimedBy != 0 {
+			rows[i].ClaimedBy = getPlayerOrUnknown(report.ClaimedBy)
+		}
+		if ids := report.ChatIDList(); len(ids) != 0 {
+			db.DB.Where("id IN (?)", ids).Order("id").Find(&rows[i].Messages)
+		}
+	}
+
+	err := reportsTempl.Execute(w, map[string]interface{}{
+		"XSRFToken":   xsrftoken.Generate(config.Constants.CookieStoreSecret, "admin", "POST"),
+		"Reports":     rows,
+		"FrontendURL": config.Constants.LoginRedirectPath,
+	})
+	if err != nil {
+		logrus.Error(err)
+	}
+}
+
+//getFormReport returns the report whose ID is in the form, after checking the XSRF token
+func getFormReport(w http.ResponseWriter, r *http.Request) (*player.Report, *player.Player, bool) {
+	r.ParseForm()
+	values := r.Form
+
+	token := values.Get("xsrf-token")
+	if !xsrftoken.Valid(token, config.Constants.CookieStoreSecret, "admin", "POST") {
+		http.Error(w, "invalid xsrf token", http.StatusBadRequest)
+		return nil, nil, false
+	}
+
+	id, err := strconv.ParseUint(values.Get("id"), 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid report ID", http.StatusBadRequest)
+		return nil, nil, false
+	}
+
+	report, err := player.GetReport(uint(id))
+	if err != nil || report.Type != player.Filed {
+		http.Error(w, "Report not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	jwt, _ := chelpers.GetToken(r)
+	return report, chelpers.GetPlayer(jwt), true
+}
+
+func ClaimReport(w http.ResponseWriter, r *http.Request) {
+	report, mod, ok := getFormReport(w, r)
+	if !ok {
+		return
+	}
+
+	if err := report.Claim(mod.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	chelpers.AuditHTTP(r, models.AuditClaimReport, strconv.FormatUint(uint64(report.ID), 10), "", "")
+
+	fmt.Fprintf(w, "Report #%d claimed.", report.ID)
+}
+
+func ResolveReport(w http.ResponseWriter, r *http.Request) {
+	report, mod, ok := getFormReport(w, r)
+	if !ok {
+		return
+	}
+	values := r.Form
+
+	if err := report.CanHandle(mod.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var banID uint
+	var ban player.BanType
+	var until time.Time
+	banning := values.Get("ban") != ""
+
+	if banning {
+		// ban the reported player after the report is resolved, and link the ban to it
+		var ok bool
+		ban, ok = banTypes[values.Get("ban")]
+		if !ok {
+			http.Error(w, "Invalid ban type", http.StatusBadRequest)
+			return
+		}
+		if !canBan(mod, ban) {
+			http.Error(w, "You aren't authorized to give this type of ban", http.StatusForbidden)
+			return
+		}
+
+		var err error
+		until, err = time.Parse("2006-01-02 15:04", values.Get("date")+" "+values.Get("time"))
+		if err != nil || until.Before(time.Now()) {
+			http.Error(w, "invalid time", http.StatusBadRequest)
+			return
+		}
+	} else if values.Get("banid") != "" {
+		id, err := strconv.ParseUint(values.Get("banid"), 10, 32)
+		if err != nil {
+			http.Error(w, "Invalid ban ID", http.StatusBadRequest)
+			return
+		}
+
+		var count int
+		db.DB.Model(&player.PlayerBan{}).Where("id = ? AND player_id = ?", id, report.PlayerID).Count(&count)
+		if count == 0 {
+			http.Error(w, "The reported player doesn't have a ban with that ID", http.StatusBadRequest)
+			return
+		}
+		banID = uint(id)
+	}
+
+	// only one request can resolve the report, so resolving it twice doesn't ban the player twice
+	outcome := values.Get("outcome")
+	if err := report.Resolve(mod.ID, outcome, banID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	chelpers.AuditHTTP(r, models.AuditResolveReport, strconv.FormatUint(uint64(report.ID), 10), "", outcome)
+
+	if banning {
+		reported, err := player.GetPlayerByID(report.PlayerID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		reason := values.Get("reason")
+		if reason == "" {
+			reason = fmt.Sprintf("Report #%d (%s)", report.ID, report.Category)
+		}
+		if err := reported.BanUntil(until, ban, reason, mod.ID); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		chelpers.AuditHTTP(r, models.AuditBan, reported.SteamID, "", fmt.Sprintf("%s till %s: %s", ban.String(), until.Format(time.RFC822), reason))
+
+		if playerBan, err := reported.GetActiveBan(ban); err == nil {
+			report.SetBan(playerBan.ID)
+		}
+	}
+
+	if reporter, err := player.GetPlayerByID(report.ReporterID); err == nil {
+		notification.Send(reporter.ID, reporter.SteamID, notification.Report,
+			fmt.Sprintf("Your report #%d has been resolved: %s", report.ID, outcome), report)
+	}
+
+	fmt.Fprintf(w, "Report #%d resolved.", report.ID)
+}
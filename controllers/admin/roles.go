@@ -6,15 +6,23 @@ package admin
 
 import (
 	"fmt"
+	"html/template"
 	"net/http"
+	"sort"
 
+	"github.com/sirupsen/logrus"
 	"github.com/TF2Stadium/Helen/config"
+	chelpers "github.com/TF2Stadium/Helen/controllers/controllerhelpers"
 	"github.com/TF2Stadium/Helen/helpers"
 	"github.com/TF2Stadium/Helen/helpers/authority"
+	"github.com/TF2Stadium/Helen/models"
 	"github.com/TF2Stadium/Helen/models/player"
+	"github.com/TF2Stadium/Helen/models/role"
 	"golang.org/x/net/xsrftoken"
 )
 
+var rolesTempl *template.Template
+
 func ChangeRole(w http.ResponseWriter, r *http.Request) {
 	err := r.ParseForm()
 	if err != nil {
@@ -31,11 +39,8 @@ func ChangeRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	role, ok := map[string]authority.AuthRole{
-		"admin": helpers.RoleAdmin,
-		"mod":   helpers.RoleMod,
-	}[values.Get("role")]
-	if !ok {
+	newRole, err := role.GetRole(values.Get("role"))
+	if err != nil {
 		http.Error(w, "invalid role", http.StatusBadRequest)
 		return
 	}
@@ -46,16 +51,21 @@ func ChangeRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	oldRole := player.Role.Name()
 	if remove == "true" {
 		player.Role = 0
 		player.Save()
-		fmt.Fprintf(w, "Player %s (%s) has been removed as %s", player.Name, player.SteamID, helpers.RoleNames[role])
+		player.InvalidateSessions()
+		chelpers.AuditHTTP(r, models.AuditChangeRole, player.SteamID, oldRole, player.Role.Name())
+		fmt.Fprintf(w, "Player %s (%s) has been removed as %s", player.Name, player.SteamID, newRole.Name())
 		return
 	}
 
-	player.Role = role
+	player.Role = newRole
 	player.Save()
-	fmt.Fprintf(w, "Player %s (%s) has been made a %s", player.Name, player.SteamID, helpers.RoleNames[role])
+	player.InvalidateSessions()
+	chelpers.AuditHTTP(r, models.AuditChangeRole, player.SteamID, oldRole, newRole.Name())
+	fmt.Fprintf(w, "Player %s (%s) has been made a %s", player.Name, player.SteamID, newRole.Name())
 	return
 }
 
@@ -73,7 +83,134 @@ func Remove(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	oldRole := player.Role.Name()
 	player.Role = authority.AuthRole(0)
 	player.Save()
+	player.InvalidateSessions()
+	chelpers.AuditHTTP(r, models.AuditChangeRole, player.SteamID, oldRole, player.Role.Name())
 	fmt.Fprintf(w, "%s (%s) is no longer an admin/mod", player.Name, player.SteamID)
 }
+
+func ViewRoles(w http.ResponseWriter, r *http.Request) {
+	var actions []string
+	for _, name := range helpers.ActionNames {
+		actions = append(actions, name)
+	}
+	sort.Strings(actions)
+
+	roles := role.GetAllRoles()
+	// actions each role can do, including inherited ones
+	effective := make(map[string][]string)
+	for _, rl := range roles {
+		for action, name := range helpers.ActionNames {
+			if rl.Number.Can(action) {
+				effective[rl.Name] = append(effective[rl.Name], name)
+			}
+		}
+		sort.Strings(effective[rl.Name])
+	}
+
+	err := rolesTempl.Execute(w, map[string]interface{}{
+		"XSRFToken": xsrftoken.Generate(config.Constants.CookieStoreSecret, "admin", "POST"),
+		"Roles":     roles,
+		"Effective": effective,
+		"Actions":   actions,
+	})
+	if err != nil {
+		logrus.Error(err)
+	}
+}
+
+func AddRole(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	values := r.Form
+
+	token := values.Get("xsrf-token")
+	if !xsrftoken.Valid(token, config.Constants.CookieStoreSecret, "admin", "POST") {
+		http.Error(w, "invalid xsrf token", http.StatusBadRequest)
+		return
+	}
+
+	err := role.AddRole(values.Get("name"), values.Get("parent"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	chelpers.AuditHTTP(r, models.AuditAddRole, values.Get("name"), "", values.Get("parent"))
+
+	fmt.Fprintf(w, "Role successfully added.")
+}
+
+func SetRoleParent(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	values := r.Form
+
+	token := values.Get("xsrf-token")
+	if !xsrftoken.Valid(token, config.Constants.CookieStoreSecret, "admin", "POST") {
+		http.Error(w, "invalid xsrf token", http.StatusBadRequest)
+		return
+	}
+
+	var oldParent string
+	for _, rl := range role.GetAllRoles() {
+		if rl.Name == values.Get("role") {
+			oldParent = rl.Parent
+		}
+	}
+
+	err := role.SetParent(values.Get("role"), values.Get("parent"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	chelpers.AuditHTTP(r, models.AuditSetRoleParent, values.Get("role"), oldParent, values.Get("parent"))
+
+	fmt.Fprintf(w, "Role successfully changed.")
+}
+
+func GrantRoleAction(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	values := r.Form
+
+	token := values.Get("xsrf-token")
+	if !xsrftoken.Valid(token, config.Constants.CookieStoreSecret, "admin", "POST") {
+		http.Error(w, "invalid xsrf token", http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	action := models.AuditGrantAction
+	if values.Get("remove") == "true" {
+		err = role.Disallow(values.Get("role"), values.Get("action"))
+		action = models.AuditRevokeAction
+	} else {
+		err = role.Allow(values.Get("role"), values.Get("action"))
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	chelpers.AuditHTTP(r, action, values.Get("role"), "", values.Get("action"))
+
+	fmt.Fprintf(w, "Role successfully changed.")
+}
+
+func RemoveRole(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	values := r.Form
+
+	token := values.Get("xsrf-token")
+	if !xsrftoken.Valid(token, config.Constants.CookieStoreSecret, "admin", "POST") {
+		http.Error(w, "invalid xsrf token", http.StatusBadRequest)
+		return
+	}
+
+	err := role.RemoveRole(values.Get("role"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	chelpers.AuditHTTP(r, models.AuditRemoveRole, values.Get("role"), "", "")
+
+	fmt.Fprintf(w, "Role successfully removed.")
+}
@@ -4,10 +4,15 @@ import (
 	"fmt"
 	"html/template"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/TF2Stadium/Helen/config"
+	chelpers "github.com/TF2Stadium/Helen/controllers/controllerhelpers"
+	"github.com/TF2Stadium/Helen/models"
 	"github.com/TF2Stadium/Helen/models/gameserver"
+	"github.com/TF2Stadium/Helen/models/lobby"
 	"golang.org/x/net/xsrftoken"
 )
 
@@ -47,6 +52,7 @@ func AddServer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	chelpers.AuditHTTP(r, models.AuditAddServer, addr, "", name)
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintf(w, "Server successfully added (ID: #%d)", server.ID)
 }
@@ -68,13 +74,41 @@ func RemoveServer(w http.ResponseWriter, r *http.Request) {
 	}
 
 	gameserver.RemoveStoredServer(addr)
+	chelpers.AuditHTTP(r, models.AuditRemoveServer, addr, "", "")
 	fmt.Fprintf(w, "Server successfully deleted.")
 }
 
+func SetMaxExtension(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	values := r.Form
+
+	token := values.Get("xsrf-token")
+	if !xsrftoken.Valid(token, config.Constants.CookieStoreSecret, "admin", "POST") {
+		http.Error(w, "invalid xsrf token", http.StatusBadRequest)
+		return
+	}
+
+	minutes, err := strconv.Atoi(values.Get("minutes"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	before := lobby.GetMaxExtension()
+	if err := lobby.SetMaxExtension(time.Duration(minutes) * time.Minute); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	chelpers.AuditHTTP(r, models.AuditMaxExtension, "", before.String(), lobby.GetMaxExtension().String())
+
+	fmt.Fprintf(w, "Maximum extension successfully updated.")
+}
+
 func ViewServerPage(w http.ResponseWriter, r *http.Request) {
 	err := serverPage.Execute(w, map[string]interface{}{
-		"XSRFToken": xsrftoken.Generate(config.Constants.CookieStoreSecret, "admin", "POST"),
-		"Servers":   gameserver.GetAllStoredServers(),
+		"XSRFToken":    xsrftoken.Generate(config.Constants.CookieStoreSecret, "admin", "POST"),
+		"Servers":      gameserver.GetAllStoredServers(),
+		"MaxExtension": int(lobby.GetMaxExtension() / time.Minute),
 	})
 	if err != nil {
 		logrus.Error(err)
@@ -9,5 +9,18 @@ func InitAdminTemplates() {
 	banlogsTempl = template.Must(template.ParseFiles("views/admin/templates/ban_logs.html"))
 	chatLogsTempl = template.Must(template.ParseFiles("views/admin/templates/chatlogs.html"))
 	lobbiesTempl = template.Must(template.ParseFiles("views/admin/templates/lobbies.html"))
+	timelineTempl = template.Must(template.ParseFiles("views/admin/templates/lobby_timeline.html"))
+	demosTempl = template.Must(template.ParseFiles("views/admin/templates/demos.html"))
+	regionsTempl = template.Must(template.ParseFiles("views/admin/templates/regions.html"))
+	rolesTempl = template.Must(template.ParseFiles("views/admin/templates/roles.html"))
+	auditTempl = template.Must(template.ParseFiles("views/admin/templates/audit.html"))
+	reportsTempl = template.Must(template.ParseFiles("views/admin/templates/reports.html"))
+	appealsTempl = template.Must(template.ParseFiles("views/admin/templates/appeals.html"))
+	appealTempl = template.Must(template.ParseFiles("views/admin/templates/appeal.html"))
+	altsTempl = template.Must(template.ParseFiles("views/admin/templates/alts.html"))
+	chatModTempl = template.Must(template.ParseFiles("views/admin/templates/chat_moderation.html"))
+	directTempl = template.Must(template.ParseFiles("views/admin/templates/direct_messages.html"))
+	chatSearchTempl = template.Must(template.ParseFiles("views/admin/templates/chat_search.html"))
+	chatContextTempl = template.Must(template.ParseFiles("views/admin/templates/chat_context.html"))
 	adminPageTempl = template.Must(template.ParseFiles("views/admin/index.html"))
 }
@@ -0,0 +1,162 @@
+// Copyright (C) 2015  TF2Stadium
+// Use of this source code is governed by the GPLv3
+// that can be found in the COPYING file.
+
+//Package api serves the public, read-only JSON API under /api/v1/
+package api
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/TF2Stadium/Helen/config"
+	chelpers "github.com/TF2Stadium/Helen/controllers/controllerhelpers"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 50
+)
+
+//page is the pagination requested with ?page=<n>&limit=<n>, pages start at 1
+type page struct {
+	Page  int `json:"page"`
+	Limit int `json:"limit"`
+}
+
+func (p page) offset() int {
+	return (p.Page - 1) * p.Limit
+}
+
+func getPage(r *http.Request) page {
+	values := r.URL.Query()
+	p := page{Page: 1, Limit: defaultLimit}
+
+	if n, err := strconv.Atoi(values.Get("page")); err == nil && n > 0 {
+		p.Page = n
+	}
+	if n, err := strconv.Atoi(values.Get("limit")); err == nil && n > 0 {
+		p.Limit = n
+	}
+	if p.Limit > maxLimit {
+		p.Limit = maxLimit
+	}
+
+	return p
+}
+
+//paginated is the body of every response containing a list
+type paginated struct {
+	page
+	Total int         `json:"total"`
+	Data  interface{} `json:"data"`
+}
+
+//writeJSON writes v as the response, with an ETag computed from the body.
+//If the client already has the same version, 304 is sent instead.
+func writeJSON(w http.ResponseWriter, r *http.Request, v interface{}) {
+	bytes, err := json.Marshal(v)
+	if err != nil {
+		logrus.Error(err)
+		writeError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	sum := sha1.Sum(bytes)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bytes)
+}
+
+func writeError(w http.ResponseWriter, msg string, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{msg})
+}
+
+//limiter counts requests per IP in fixed one minute windows
+type limiter struct {
+	mu     *sync.Mutex
+	window time.Time
+	counts map[string]int
+}
+
+var rateLimiter = newLimiter()
+
+func newLimiter() *limiter {
+	return &limiter{
+		mu:     new(sync.Mutex),
+		counts: make(map[string]int),
+	}
+}
+
+//allow records a request from ip, and returns whether it's within limit.
+//The second return value is the number of requests left in the current window.
+func (l *limiter) allow(ip string, limit int, now time.Time) (bool, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if window := now.Truncate(time.Minute); !window.Equal(l.window) {
+		l.window = window
+		l.counts = make(map[string]int)
+	}
+
+	l.counts[ip]++
+	left := limit - l.counts[ip]
+	if left < 0 {
+		return false, 0
+	}
+	return true, left
+}
+
+//RateLimit wraps f so it only accepts GET requests, limited to
+//API_RATE_LIMIT requests per minute for each IP address or API token
+func RateLimit(f http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" && r.Method != "HEAD" {
+			writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// requests with an API token are limited per token instead of per IP
+		key := chelpers.GetIPAddr(r)
+		if r.Header.Get("Authorization") != "" {
+			token, err := chelpers.GetAPIToken(r)
+			if err != nil {
+				writeError(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			key = "token:" + strconv.FormatUint(uint64(token.Claims.(*chelpers.TF2StadiumClaims).TokenID), 10)
+		}
+
+		limit := config.Constants.APIRateLimit
+		if limit != 0 {
+			ok, left := rateLimiter.allow(key, limit, time.Now())
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(left))
+			if !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(60-time.Now().Second()))
+				writeError(w, "Too many requests", http.StatusTooManyRequests)
+				return
+			}
+		}
+
+		f(w, r)
+	}
+}
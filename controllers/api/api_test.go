@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPage(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/v1/lobbies?page=3&limit=10", nil)
+	p := getPage(r)
+	assert.Equal(t, page{3, 10}, p)
+	assert.Equal(t, 20, p.offset())
+
+	r = httptest.NewRequest("GET", "/api/v1/lobbies?page=-1&limit=1000", nil)
+	assert.Equal(t, page{1, maxLimit}, getPage(r))
+
+	r = httptest.NewRequest("GET", "/api/v1/lobbies", nil)
+	assert.Equal(t, page{1, defaultLimit}, getPage(r))
+}
+
+func TestETag(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/v1/subs", nil)
+	w := httptest.NewRecorder()
+	writeJSON(w, r, []int{1, 2, 3})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "[1,2,3]", w.Body.String())
+
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	writeJSON(w, r, []int{1, 2, 3})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	w = httptest.NewRecorder()
+	writeJSON(w, r, []int{1, 2})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter()
+	now := time.Date(2016, 1, 1, 20, 0, 10, 0, time.UTC)
+
+	for i := 2; i >= 0; i-- {
+		ok, left := l.allow("1.2.3.4", 3, now)
+		assert.True(t, ok)
+		assert.Equal(t, i, left)
+	}
+	ok, _ := l.allow("1.2.3.4", 3, now)
+	assert.False(t, ok)
+
+	// other addresses have their own limit
+	ok, _ = l.allow("5.6.7.8", 3, now)
+	assert.True(t, ok)
+
+	// limits are reset every minute
+	ok, _ = l.allow("1.2.3.4", 3, now.Add(time.Minute))
+	assert.True(t, ok)
+}
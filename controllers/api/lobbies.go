@@ -0,0 +1,62 @@
+// Copyright (C) 2015  TF2Stadium
+// Use of this source code is governed by the GPLv3
+// that can be found in the COPYING file.
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	db "github.com/TF2Stadium/Helen/database"
+	"github.com/TF2Stadium/Helen/models/lobby"
+)
+
+//Lobbies serves /api/v1/lobbies, the list of lobbies waiting for players
+func Lobbies(w http.ResponseWriter, r *http.Request) {
+	p := getPage(r)
+	var lobbies []*lobby.Lobby
+	var total int
+
+	db.DB.Model(&lobby.Lobby{}).Where("state = ?", lobby.Waiting).Count(&total)
+	db.DB.Where("state = ?", lobby.Waiting).Order("id desc").
+		Offset(p.offset()).Limit(p.Limit).Find(&lobbies)
+
+	writeJSON(w, r, paginated{p, total, lobby.DecorateLobbyListData(lobbies, false)})
+}
+
+//Lobby serves /api/v1/lobbies/<id>, the details of a single lobby
+func Lobby(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/api/v1/lobbies/"), 10, 32)
+	if err != nil {
+		writeError(w, "Invalid lobby ID", http.StatusBadRequest)
+		return
+	}
+
+	lob, err := lobby.GetLobbyByID(uint(id))
+	if err != nil {
+		writeError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, r, lobby.DecorateLobbyData(lob, true))
+}
+
+//Substitutes serves /api/v1/subs, the list of slots in lobbies needing a substitute
+func Substitutes(w http.ResponseWriter, r *http.Request) {
+	p := getPage(r)
+	subs := lobby.DecorateSubstituteList()
+	total := len(subs)
+
+	if p.offset() >= total {
+		subs = subs[:0]
+	} else {
+		subs = subs[p.offset():]
+		if len(subs) > p.Limit {
+			subs = subs[:p.Limit]
+		}
+	}
+
+	writeJSON(w, r, paginated{p, total, subs})
+}
@@ -0,0 +1,103 @@
+// Copyright (C) 2015  TF2Stadium
+// Use of this source code is governed by the GPLv3
+// that can be found in the COPYING file.
+
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	chelpers "github.com/TF2Stadium/Helen/controllers/controllerhelpers"
+	db "github.com/TF2Stadium/Helen/database"
+	"github.com/TF2Stadium/Helen/models/apitoken"
+	"github.com/TF2Stadium/Helen/models/lobby"
+	"github.com/TF2Stadium/Helen/models/player"
+)
+
+//Player serves /api/v1/players/<steamid> (the player's profile),
+///api/v1/players/<steamid>/lobbies (lobbies the player played in), and
+///api/v1/players/<steamid>/bans (the player's active bans)
+func Player(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/players/"), "/")
+
+	p, err := player.GetPlayerBySteamID(parts[0])
+	if err != nil {
+		writeError(w, "Player with given SteamID not found", http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case len(parts) == 1:
+		p.SetPlayerProfile()
+		writeJSON(w, r, p)
+	case len(parts) == 2 && parts[1] == "lobbies":
+		playerLobbies(w, r, p)
+	case len(parts) == 2 && parts[1] == "bans":
+		bans, _ := p.GetActiveBans()
+		if bans == nil {
+			bans = []*player.PlayerBan{}
+		}
+		writeJSON(w, r, bans)
+	default:
+		writeError(w, "Not found", http.StatusNotFound)
+	}
+}
+
+//Me serves /api/v1/me, the profile of the player the request's token belongs to.
+//API tokens need the read-profile scope.
+func Me(w http.ResponseWriter, r *http.Request) {
+	token, err := chelpers.GetAPIToken(r)
+	if err != nil {
+		writeError(w, "Not logged in", http.StatusUnauthorized)
+		return
+	}
+	if !token.Claims.(*chelpers.TF2StadiumClaims).HasScope(apitoken.ScopeReadProfile) {
+		writeError(w, "Your API token doesn't have the read-profile scope", http.StatusForbidden)
+		return
+	}
+
+	p := chelpers.GetPlayer(token)
+	p.SetPlayerProfile()
+	writeJSON(w, r, p)
+}
+
+func playerLobbies(w http.ResponseWriter, r *http.Request, p *player.Player) {
+	pg := getPage(r)
+	var lobbies []*lobby.Lobby
+	var total int
+
+	query := db.DB.Model(&lobby.Lobby{}).Joins("INNER JOIN lobby_slots ON lobbies.ID = lobby_slots.lobby_id").
+		Where("lobbies.match_ended = TRUE and lobby_slots.player_id = ? AND lobby_slots.needs_sub = FALSE", p.ID)
+	query.Count(&total)
+	query.Order("lobbies.id desc").Offset(pg.offset()).Limit(pg.Limit).Find(&lobbies)
+
+	writeJSON(w, r, paginated{pg, total, lobby.DecorateLobbyListData(lobbies, true)})
+}
+
+type ban struct {
+	SteamID string    `json:"steamid"`
+	Name    string    `json:"name"`
+	Type    string    `json:"type"`
+	Until   time.Time `json:"until"`
+	Reason  string    `json:"reason"`
+}
+
+//Bans serves /api/v1/bans, the list of active bans
+func Bans(w http.ResponseWriter, r *http.Request) {
+	p := getPage(r)
+	var bans []*player.PlayerBan
+	var total int
+
+	query := db.DB.Model(&player.PlayerBan{}).Where("active = TRUE AND until > now()")
+	query.Count(&total)
+	query.Preload("Player").Order("id desc").Offset(p.offset()).Limit(p.Limit).Find(&bans)
+
+	list := make([]ban, len(bans))
+	for i, b := range bans {
+		list[i] = ban{b.Player.SteamID, b.Player.Alias(), b.Type.String(), b.Until, b.Reason}
+	}
+
+	writeJSON(w, r, paginated{p, total, list})
+}
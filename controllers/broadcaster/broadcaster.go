@@ -5,6 +5,9 @@
 package broadcaster
 
 import (
+	"encoding/json"
+
+	"github.com/sirupsen/logrus"
 	"github.com/TF2Stadium/Helen/controllers/socket/sessions"
 	"github.com/TF2Stadium/Helen/helpers"
 	"github.com/TF2Stadium/Helen/routes/socket"
@@ -33,6 +36,34 @@ func SendMessageToRoom(r string, event string, content interface{}) {
 	socket.UnauthServer.BroadcastJSON(r, v)
 }
 
+//SendMessageToRoomSkip is like SendMessageToRoom, but the message isn't sent
+//to the sockets of the logged in players in skip
+func SendMessageToRoomSkip(r string, event string, content interface{}, skip map[string]bool) {
+	// marshaled once, instead of for every socket
+	raw, err := json.Marshal(content)
+	if err != nil {
+		logrus.Error(err)
+		return
+	}
+	v := helpers.NewRequest(event, json.RawMessage(raw))
+
+	socket.UnauthServer.BroadcastJSON(r, v)
+	sessions.RoomMembers(r, func(so *wsevent.Client, steamid string) {
+		if !skip[steamid] {
+			go so.EmitJSON(v)
+		}
+	})
+}
+
+//CloseSockets closes the player's sockets, so they reconnect with a new token.
+//Sockets using API tokens aren't the player's sockets, and are kept open.
+func CloseSockets(steamid string) {
+	sockets, _ := sessions.GetSockets(steamid)
+	for _, so := range sockets {
+		so.Close()
+	}
+}
+
 func SendMessageSkipIDs(skipID, steamid, event string, content interface{}) {
 	sockets, ok := sessions.GetSockets(steamid)
 	if !ok {
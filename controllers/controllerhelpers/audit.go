@@ -0,0 +1,39 @@
+package controllerhelpers
+
+import (
+	"net/http"
+
+	"github.com/TF2Stadium/Helen/models"
+	"github.com/TF2Stadium/wsevent"
+	"github.com/sirupsen/logrus"
+)
+
+func audit(playerID uint, ipaddr, action, target, before, after string) {
+	err := models.LogAudit(&models.AdminLogEntry{
+		PlayerID: playerID,
+		Action:   action,
+		Target:   target,
+		Before:   before,
+		After:    after,
+		IPAddr:   ipaddr,
+	})
+	if err != nil {
+		logrus.Error(err)
+	}
+}
+
+//AuditHTTP records a privileged action done through the admin pages
+func AuditHTTP(r *http.Request, action, target, before, after string) {
+	token, err := GetToken(r)
+	if err != nil {
+		logrus.Error(err)
+		return
+	}
+
+	audit(token.Claims.(*TF2StadiumClaims).PlayerID, GetIPAddr(r), action, target, before, after)
+}
+
+//AuditSocket records a privileged action done over the socket
+func AuditSocket(so *wsevent.Client, action, target, before, after string) {
+	audit(so.Token.Claims.(*TF2StadiumClaims).PlayerID, GetIPAddr(so.Request), action, target, before, after)
+}
@@ -7,7 +7,12 @@ import (
 )
 
 func BroadcastScrollback(so *wsevent.Client, room uint) {
-	messages, err := chat.GetScrollback(int(room))
+	var playerID uint
+	if so.Token != nil {
+		playerID = so.Token.Claims.(*TF2StadiumClaims).PlayerID
+	}
+
+	messages, err := chat.GetScrollback(int(room), playerID)
 	if err != nil {
 		return
 	}
@@ -1,6 +1,9 @@
 package controllerhelpers
 
 import (
+	"errors"
+	"time"
+
 	db "github.com/TF2Stadium/Helen/database"
 	"github.com/TF2Stadium/Helen/helpers/authority"
 	"github.com/TF2Stadium/Helen/models/player"
@@ -13,6 +16,13 @@ type TF2StadiumClaims struct {
 	Role           authority.AuthRole `json:"role"`
 	IssuedAt       int64              `json:"iat"`
 	Issuer         string             `json:"iss"`
+	ExpiresAt      int64              `json:"exp"`
+	SessionID      uint               `json:"sid"`
+	Generation     int                `json:"gen"` // session generation the token was issued for
+
+	// only set for personal API tokens, which aren't JWTs
+	TokenID uint     `json:"-"`
+	Scopes  []string `json:"-"`
 }
 
 func playerExists(id uint, steamID string) bool {
@@ -21,10 +31,33 @@ func playerExists(id uint, steamID string) bool {
 	return count != 0
 }
 
+var ErrTokenExpired = errors.New("Token expired")
+
 func (c TF2StadiumClaims) Valid() error {
+	if time.Now().Unix() >= c.ExpiresAt {
+		return ErrTokenExpired
+	}
 	if !playerExists(c.PlayerID, c.SteamID) {
 		return player.ErrPlayerNotFound
 	}
+	if !player.IsSessionValid(c.SessionID, c.PlayerID, c.Generation) {
+		return player.ErrSessionInvalid
+	}
 
 	return nil
 }
+
+//HasScope returns true if the client can make requests needing scope.
+//Logins through Steam can do everything.
+func (c TF2StadiumClaims) HasScope(scope string) bool {
+	if c.TokenID == 0 {
+		return true
+	}
+
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -87,7 +87,13 @@ func FilterHTTPRequest(action authority.AuthAction, f func(http.ResponseWriter,
 			return
 		}
 
-		if !(token.Claims.(*TF2StadiumClaims).Role.Can(action)) {
+		if token.Claims.(*TF2StadiumClaims).TokenID != 0 {
+			http.Error(w, "API tokens can't be used here", 403)
+			return
+		}
+
+		// use the player's current role, in case it was changed after the token was issued
+		if !GetPlayer(token).Role.Can(action) {
 			http.Error(w, "Not authorized", 403)
 			return
 		}
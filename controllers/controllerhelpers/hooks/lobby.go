@@ -26,7 +26,7 @@ func AfterLobbyJoin(so *wsevent.Client, lob *lobby.Lobby, player *player.Player)
 	//might close, so lobbyStart and lobbyReadyUp can be sent to other tabs
 	sockets, _ := sessions.GetSockets(player.SteamID)
 	for _, so := range sockets {
-		socket.AuthServer.Join(so, room)
+		sessions.JoinRoom(socket.AuthServer, so, player.SteamID, room)
 	}
 	if lob.State == lobby.InProgress { // player is a substitute
 		lob.AfterPlayerNotInGameFunc(player, 5*time.Minute, func() {
@@ -53,7 +53,7 @@ func AfterLobbyLeave(lob *lobby.Lobby, player *player.Player, kicked bool, notRe
 	sockets, _ := sessions.GetSockets(player.SteamID)
 	//player might have connected from multiple tabs, remove all of them from the room
 	for _, so := range sockets {
-		socket.AuthServer.Leave(so, fmt.Sprintf("%s_private", GetLobbyRoom(lob.ID)))
+		sessions.LeaveRoom(socket.AuthServer, so, fmt.Sprintf("%s_private", GetLobbyRoom(lob.ID)))
 	}
 }
 
@@ -61,7 +61,7 @@ func AfterLobbySpec(server *wsevent.Server, so *wsevent.Client, player *player.P
 	//remove socket from room of the previous lobby the socket was spectating (if any)
 	lobbyID, ok := sessions.GetSpectating(so.ID)
 	if ok {
-		server.Leave(so, fmt.Sprintf("%d_public", lobbyID))
+		sessions.LeaveRoom(server, so, fmt.Sprintf("%d_public", lobbyID))
 		sessions.RemoveSpectator(so.ID)
 		if player != nil {
 			prevLobby, _ := lobby.GetLobbyByID(lobbyID)
@@ -69,13 +69,17 @@ func AfterLobbySpec(server *wsevent.Server, so *wsevent.Client, player *player.P
 		}
 	}
 
-	server.Join(so, fmt.Sprintf("%d_public", lob.ID))
+	steamid := ""
+	if player != nil {
+		steamid = player.SteamID
+	}
+	sessions.JoinRoom(server, so, steamid, fmt.Sprintf("%d_public", lob.ID))
 	chelpers.BroadcastScrollback(so, lob.ID)
 	sessions.SetSpectator(so.ID, lob.ID)
 }
 
 func AfterLobbySpecLeave(so *wsevent.Client, lob *lobby.Lobby) {
-	socket.AuthServer.Leave(so, fmt.Sprintf("%s_public", GetLobbyRoom(lob.ID)))
+	sessions.LeaveRoom(socket.AuthServer, so, fmt.Sprintf("%s_public", GetLobbyRoom(lob.ID)))
 	sessions.RemoveSpectator(so.ID)
 }
 
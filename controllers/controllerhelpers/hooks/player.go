@@ -5,6 +5,7 @@
 package hooks
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -13,14 +14,20 @@ import (
 	"github.com/TF2Stadium/Helen/controllers/socket/sessions"
 	db "github.com/TF2Stadium/Helen/database"
 	"github.com/TF2Stadium/Helen/helpers"
+	"github.com/TF2Stadium/Helen/models/apitoken"
 	"github.com/TF2Stadium/Helen/models/lobby"
+	"github.com/TF2Stadium/Helen/models/notification"
 	"github.com/TF2Stadium/Helen/models/player"
 	"github.com/TF2Stadium/Helen/routes/socket"
 	"github.com/TF2Stadium/wsevent"
 )
 
 func AfterConnect(server *wsevent.Server, so *wsevent.Client) {
-	server.Join(so, "0_public") //room for global chat
+	steamid := ""
+	if so.Token != nil {
+		steamid = so.Token.Claims.(*chelpers.TF2StadiumClaims).SteamID
+	}
+	sessions.JoinRoom(server, so, steamid, "0_public") //room for global chat
 
 	so.EmitJSON(helpers.NewRequest("lobbyListData", lobby.DecorateLobbyListData(lobby.GetWaitingLobbies(), false)))
 	chelpers.BroadcastScrollback(so, 0)
@@ -30,6 +37,11 @@ func AfterConnect(server *wsevent.Server, so *wsevent.Client) {
 var emptyMap = make(map[string]string)
 
 func AfterConnectLoggedIn(so *wsevent.Client, player *player.Player) {
+	if so.Token.Claims.(*chelpers.TF2StadiumClaims).TokenID != 0 {
+		afterConnectAPIToken(so, player)
+		return
+	}
+
 	sessions.AddSocket(player.SteamID, so)
 
 	if time.Since(player.ProfileUpdatedAt) >= 30*time.Minute {
@@ -74,4 +86,32 @@ func AfterConnectLoggedIn(so *wsevent.Client, player *player.Player) {
 		Address  string `json:"address"`
 		Password string `json:"password"`
 	}{config.Constants.MumbleAddr, player.MumbleAuthkey}))
+	so.EmitJSON(helpers.NewRequest("logoutEverywhereToken", struct {
+		Token string `json:"token"`
+	}{chelpers.LogoutEverywhereToken(player.SteamID)}))
+	so.EmitJSON(helpers.NewRequest("notificationsUnread", struct {
+		Unread int `json:"unread"`
+	}{notification.UnreadCount(player.ID)}))
+}
+
+//afterConnectAPIToken is called instead of AfterConnectLoggedIn for sockets
+//using an API token. They aren't added to the player's sockets, so they don't
+//get messages sent to the player (direct messages, notifications, connect
+//info), and only get what their scopes allow.
+func afterConnectAPIToken(so *wsevent.Client, player *player.Player) {
+	claims := so.Token.Claims.(*chelpers.TF2StadiumClaims)
+
+	if claims.HasScope(apitoken.ScopeJoinLobby) {
+		lobbyID, err := player.GetLobbyID(false)
+		if err == nil {
+			lob, _ := lobby.GetLobbyByIDServer(lobbyID)
+			sessions.JoinRoom(socket.AuthServer, so, player.SteamID, fmt.Sprintf("%d_private", lob.ID))
+			AfterLobbySpec(socket.AuthServer, so, player, lob)
+		}
+	}
+
+	if claims.HasScope(apitoken.ScopeReadProfile) {
+		player.SetPlayerProfile()
+		so.EmitJSON(helpers.NewRequest("playerProfile", player))
+	}
 }
@@ -15,6 +15,8 @@ import (
 
 //OnDisconnect is connected when a player with a given socketID disconnects
 func OnDisconnect(socketID string, token *jwt.Token) {
+	sessions.RemoveRooms(socketID)
+
 	if token != nil { //player was logged in
 		player := chelpers.GetPlayer(token)
 		if player == nil {
@@ -4,10 +4,12 @@ import (
 	"encoding/base64"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/TF2Stadium/Helen/config"
+	"github.com/TF2Stadium/Helen/models/apitoken"
 	"github.com/TF2Stadium/Helen/models/player"
 	"github.com/dgrijalva/jwt-go"
 )
@@ -30,15 +32,20 @@ func init() {
 	}
 }
 
-func NewToken(player *player.Player) string {
+//NewToken returns a signed access token for the player's login session
+func NewToken(player *player.Player, session *player.Session) string {
+	now := time.Now()
 	token := jwt.New(jwt.SigningMethodHS512)
 	token.Claims = TF2StadiumClaims{
 		PlayerID:       player.ID,
 		SteamID:        player.SteamID,
 		MumblePassword: player.MumbleAuthkey,
 		Role:           player.Role,
-		IssuedAt:       time.Now().Unix(),
+		IssuedAt:       now.Unix(),
 		Issuer:         config.Constants.PublicAddress,
+		ExpiresAt:      now.Add(config.Constants.JWTExpiry).Unix(),
+		SessionID:      session.ID,
+		Generation:     session.Generation,
 	}
 
 	str, err := token.SignedString([]byte(signingKey))
@@ -57,6 +64,7 @@ func verifyToken(token *jwt.Token) (interface{}, error) {
 	return signingKey, nil
 }
 
+//GetToken returns the JWT in the request's auth-jwt cookie
 func GetToken(r *http.Request) (*jwt.Token, error) {
 	cookie, err := r.Cookie("auth-jwt")
 	if err != nil {
@@ -67,6 +75,49 @@ func GetToken(r *http.Request) (*jwt.Token, error) {
 	return token, err
 }
 
+//GetAPIToken returns the token for a request to the API or the socket, which
+//is either a personal API token in the Authorization header, or the JWT in
+//the auth-jwt cookie. Handlers using it must check the token's scopes.
+func GetAPIToken(r *http.Request) (*jwt.Token, error) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		return getAPIToken(r, header)
+	}
+
+	return GetToken(r)
+}
+
+//getAPIToken authenticates the API token in header ("Bearer <token>"),
+//and returns a token with the claims a login would have, without the mumble password
+func getAPIToken(r *http.Request, header string) (*jwt.Token, error) {
+	if !strings.HasPrefix(header, "Bearer ") {
+		return nil, apitoken.ErrInvalidToken
+	}
+
+	token, err := apitoken.Authenticate(strings.TrimPrefix(header, "Bearer "))
+	if err != nil {
+		return nil, err
+	}
+
+	player, err := player.GetPlayerByID(token.PlayerID)
+	if err != nil {
+		return nil, err
+	}
+
+	apitoken.LogUsage(token.ID, r.URL.Path, GetIPAddr(r))
+	return &jwt.Token{
+		Claims: &TF2StadiumClaims{
+			PlayerID: player.ID,
+			SteamID:  player.SteamID,
+			Role:     player.Role,
+			IssuedAt: token.CreatedAt.Unix(),
+			Issuer:   config.Constants.PublicAddress,
+			TokenID:  token.ID,
+			Scopes:   token.ScopeList,
+		},
+		Valid: true,
+	}, nil
+}
+
 func GetPlayer(token *jwt.Token) *player.Player {
 	player, _ := player.GetPlayerByID(token.Claims.(*TF2StadiumClaims).PlayerID)
 	return player
@@ -0,0 +1,160 @@
+package controllerhelpers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/TF2Stadium/Helen/config"
+	"github.com/TF2Stadium/Helen/controllers/socket/sessions"
+	"github.com/TF2Stadium/Helen/models/player"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/xsrftoken"
+)
+
+const refreshCookie = "auth-refresh"
+
+func setCookie(w http.ResponseWriter, name, value string, expires time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   config.Constants.CookieDomain,
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   config.Constants.SecureCookies,
+	})
+}
+
+func clearCookie(w http.ResponseWriter, name string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:   name,
+		Path:   "/",
+		Domain: config.Constants.CookieDomain,
+		MaxAge: -1,
+	})
+}
+
+//setAccessCookie sets the auth-jwt cookie to a new access token for the session
+func setAccessCookie(w http.ResponseWriter, p *player.Player, session *player.Session) string {
+	token := NewToken(p, session)
+	setCookie(w, "auth-jwt", token, session.ExpiresAt)
+	return token
+}
+
+//Login creates a new login session for the player, and sets the
+//access and refresh token cookies
+func Login(w http.ResponseWriter, r *http.Request, p *player.Player) error {
+	session, refresh, err := p.NewSession(r.UserAgent(), GetIPAddr(r))
+	if err != nil {
+		return err
+	}
+	p.RecordIP(GetIPAddr(r), player.IPLogin)
+
+	setAccessCookie(w, p, session)
+	setCookie(w, refreshCookie, refresh, session.ExpiresAt)
+	return nil
+}
+
+//RenewToken issues a new access token for the request's session, for
+//when something in the token's claims (like the mumble password) changes
+func RenewToken(w http.ResponseWriter, r *http.Request, p *player.Player) error {
+	cookie, err := r.Cookie(refreshCookie)
+	if err != nil {
+		return err
+	}
+
+	session, err := player.GetSessionByRefreshToken(cookie.Value)
+	if err != nil {
+		return err
+	}
+
+	setAccessCookie(w, p, session)
+	return nil
+}
+
+//Logout revokes the request's session, and clears the cookies
+func Logout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(refreshCookie); err == nil {
+		if session, err := player.GetSessionByRefreshToken(cookie.Value); err == nil {
+			if p, err := player.GetPlayerByID(session.PlayerID); err == nil {
+				p.RevokeSession(session.ID)
+				CloseSessionSockets(p.SteamID, session.ID)
+			}
+		}
+	}
+
+	clearCookie(w, "auth-jwt")
+	clearCookie(w, refreshCookie)
+}
+
+//LogoutEverywhere revokes all of the player's sessions, and closes their
+//socket connections. Connections using API tokens aren't affected.
+func LogoutEverywhere(p *player.Player) {
+	p.RevokeSessions()
+	CloseSessionSockets(p.SteamID, 0)
+}
+
+//LogoutEverywhereToken returns the xsrf token the player has to send to /logoutAll
+func LogoutEverywhereToken(steamID string) string {
+	return xsrftoken.Generate(config.Constants.CookieStoreSecret, steamID, "logoutAll")
+}
+
+//CloseSessionSockets closes the player's socket connections made with the given
+//session, or all sessions when sessionID is 0
+func CloseSessionSockets(steamID string, sessionID uint) {
+	sockets, _ := sessions.GetSockets(steamID)
+	for _, so := range sockets {
+		claims := so.Token.Claims.(*TF2StadiumClaims)
+		if claims.TokenID == 0 && (sessionID == 0 || claims.SessionID == sessionID) {
+			so.Close()
+		}
+	}
+}
+
+//RefreshSession wraps handler, and issues a new access token when the
+//request's token has expired or been invalidated, but the refresh token
+//is still valid. Handlers see the new token in the request.
+func RefreshSession(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			refresh(w, r)
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func refresh(w http.ResponseWriter, r *http.Request) {
+	if _, err := GetToken(r); err == nil {
+		return
+	}
+
+	cookie, err := r.Cookie(refreshCookie)
+	if err != nil {
+		return
+	}
+
+	session, err := player.GetSessionByRefreshToken(cookie.Value)
+	if err != nil {
+		clearCookie(w, refreshCookie)
+		return
+	}
+
+	p, err := player.GetPlayerByID(session.PlayerID)
+	if err != nil {
+		logrus.Error(err)
+		return
+	}
+
+	session.Touch()
+	token := setAccessCookie(w, p, session)
+
+	// replace the old token in the request, so handlers use the new one
+	cookies := r.Cookies()
+	r.Header.Del("Cookie")
+	for _, c := range cookies {
+		if c.Name != "auth-jwt" {
+			r.AddCookie(c)
+		}
+	}
+	r.AddCookie(&http.Cookie{Name: "auth-jwt", Value: token})
+}
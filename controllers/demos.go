@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/TF2Stadium/Helen/models/demo"
+	"github.com/TF2Stadium/Helen/models/player"
+)
+
+//DemoList serves a JSON list of demos, either for a lobby (?lobby=<id>)
+//or for a player (?steamid=<steamid>&before=<demo id>)
+func DemoList(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	var demos []*demo.Demo
+
+	switch {
+	case values.Get("lobby") != "":
+		id, err := strconv.ParseUint(values.Get("lobby"), 10, 32)
+		if err != nil {
+			http.Error(w, "Invalid lobby ID", http.StatusBadRequest)
+			return
+		}
+		demos = demo.GetLobbyDemos(uint(id))
+
+	case values.Get("steamid") != "":
+		p, err := player.GetPlayerBySteamID(values.Get("steamid"))
+		if err != nil {
+			http.Error(w, "Player with given SteamID not found", http.StatusNotFound)
+			return
+		}
+
+		before, _ := strconv.ParseUint(values.Get("before"), 10, 32)
+		demos = demo.GetPlayerDemos(p.ID, uint(before), 50)
+
+	default:
+		http.Error(w, "Either lobby or steamid is needed", http.StatusBadRequest)
+		return
+	}
+
+	if demos == nil {
+		demos = []*demo.Demo{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(demos)
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/TF2Stadium/Helen/database"
 	"github.com/TF2Stadium/Helen/models/player"
 	openid "github.com/yohcop/openid-go"
+	"golang.org/x/net/xsrftoken"
 )
 
 var (
@@ -73,32 +74,50 @@ func SteamMockLoginHandler(w http.ResponseWriter, r *http.Request) {
 		logrus.Error(err)
 	}
 
-	key := controllerhelpers.NewToken(p)
-	cookie := &http.Cookie{
-		Name:    "auth-jwt",
-		Value:   key,
-		Path:    "/",
-		Domain:  config.Constants.CookieDomain,
-		Expires: time.Now().Add(30 * 24 * time.Hour),
-		//Secure: true,
+	if err := controllerhelpers.Login(w, r, p); err != nil {
+		logrus.Error(err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
 	}
 
-	http.SetCookie(w, cookie)
-
 	http.Redirect(w, r, config.Constants.LoginRedirectPath, 303)
 }
 
 func SteamLogoutHandler(w http.ResponseWriter, r *http.Request) {
-	cookie, err := r.Cookie("auth-jwt")
-	if err != nil { //user wasn't even logged in ಠ_ಠ
+	if _, err := r.Cookie("auth-jwt"); err != nil { //user wasn't even logged in ಠ_ಠ
 		return
 	}
 
-	cookie.Domain = config.Constants.CookieDomain
-	cookie.MaxAge = -1
-	cookie.Expires = time.Time{}
-	http.SetCookie(w, cookie)
+	controllerhelpers.Logout(w, r)
+	http.Redirect(w, r, config.Constants.LoginRedirectPath, 303)
+}
+
+//LogoutEverywhereHandler logs the player out of all their sessions. It needs a
+//POST with the xsrf token from controllerhelpers.LogoutEverywhereToken.
+func LogoutEverywhereHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
 
+	token, err := controllerhelpers.GetToken(r)
+	if err != nil {
+		http.Error(w, "You aren't logged in.", http.StatusForbidden)
+		return
+	}
+	if token.Claims.(*controllerhelpers.TF2StadiumClaims).TokenID != 0 {
+		http.Error(w, "API tokens can't be used here", http.StatusForbidden)
+		return
+	}
+
+	steamID := token.Claims.(*controllerhelpers.TF2StadiumClaims).SteamID
+	if !xsrftoken.Valid(r.FormValue("xsrf-token"), config.Constants.CookieStoreSecret, steamID, "logoutAll") {
+		http.Error(w, "invalid xsrf token", http.StatusBadRequest)
+		return
+	}
+
+	controllerhelpers.LogoutEverywhere(controllerhelpers.GetPlayer(token))
+	controllerhelpers.Logout(w, r)
 	http.Redirect(w, r, config.Constants.LoginRedirectPath, 303)
 }
 
@@ -157,18 +176,12 @@ func SteamLoginCallbackHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	key := controllerhelpers.NewToken(p)
-	cookie := &http.Cookie{
-		Name:     "auth-jwt",
-		Value:    key,
-		Path:     "/",
-		Domain:   config.Constants.CookieDomain,
-		Expires:  time.Now().Add(30 * 24 * time.Hour),
-		HttpOnly: true,
-		Secure:   config.Constants.SecureCookies,
+	if err := controllerhelpers.Login(w, r, p); err != nil {
+		logrus.Error(err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
 	}
 
-	http.SetCookie(w, cookie)
 	if refererURL != "" {
 		http.Redirect(w, r, refererURL, 303)
 		return
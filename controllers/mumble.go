@@ -2,7 +2,6 @@ package controllers
 
 import (
 	"net/http"
-	"time"
 
 	"github.com/TF2Stadium/Helen/config"
 	chelpers "github.com/TF2Stadium/Helen/controllers/controllerhelpers"
@@ -14,20 +13,20 @@ func ResetMumblePassword(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "You aren't logged in.", http.StatusForbidden)
 		return
 	}
+	if token.Claims.(*chelpers.TF2StadiumClaims).TokenID != 0 {
+		http.Error(w, "API tokens can't be used here", http.StatusForbidden)
+		return
+	}
 
 	player := chelpers.GetPlayer(token)
 	player.MumbleAuthkey = player.GenAuthKey()
 	player.Save()
 
-	newToken := chelpers.NewToken(player)
-	cookie := &http.Cookie{
-		Name:    "auth-jwt",
-		Value:   newToken,
-		Path:    "/",
-		Domain:  config.Constants.CookieDomain,
-		Expires: time.Now().Add(30 * 24 * time.Hour),
+	// the mumble password is in the token's claims
+	if err := chelpers.RenewToken(w, r, player); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
 	}
-	http.SetCookie(w, cookie)
 
 	referer, ok := r.Header["Referer"]
 	if ok {
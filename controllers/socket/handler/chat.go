@@ -7,14 +7,19 @@ package handler
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/TF2Stadium/Helen/controllers/broadcaster"
 	chelpers "github.com/TF2Stadium/Helen/controllers/controllerhelpers"
+	"github.com/TF2Stadium/Helen/controllers/socket/sessions"
 	db "github.com/TF2Stadium/Helen/database"
 	"github.com/TF2Stadium/Helen/helpers"
+	"github.com/TF2Stadium/Helen/models"
 	"github.com/TF2Stadium/Helen/models/chat"
 	"github.com/TF2Stadium/Helen/models/lobby"
+	"github.com/TF2Stadium/Helen/models/notification"
 	"github.com/TF2Stadium/Helen/models/player"
 	"github.com/TF2Stadium/wsevent"
 )
@@ -30,18 +35,22 @@ func (Chat) ChatSend(so *wsevent.Client, args struct {
 	Room    *int    `json:"room"`
 }) interface{} {
 	p := chelpers.GetPlayer(so.Token)
+	// checked first, since it doesn't touch the database
+	room := *args.Room
+	if room < 0 {
+		room = 0
+	}
+	if err := sessions.AllowChat(p.SteamID, room, *args.Message); err != nil {
+		return err
+	}
+
 	if banned, until := p.IsBannedWithTime(player.BanChat); banned {
 		ban, _ := p.GetActiveBan(player.BanChat)
 		return fmt.Errorf("You've been banned from creating lobbies till %s (%s)", until.Format(time.RFC822), ban.Reason)
 	}
 
 	if *args.Room > 0 {
-		//Check if player has either joined, or is spectating lobby
-		var count int
-		spec := p.IsSpectatingID(uint(*args.Room))
-		db.DB.Model(&lobby.LobbySlot{}).Where("lobby_id = ? AND player_id = ?", *args.Room, p.ID).Count(&count)
-
-		if !spec && count == 0 {
+		if !inRoom(p, *args.Room) {
 			return errors.New("Player is not in the lobby.")
 		}
 	} else {
@@ -59,7 +68,28 @@ func (Chat) ChatSend(so *wsevent.Client, args struct {
 		return errors.New("Message too long")
 	}
 
-	message := chat.NewChatMessage(*args.Message, *args.Room, p)
+	if mute := chat.GetMute(p.ID, *args.Room); mute != nil {
+		return fmt.Errorf("You've been muted in this room till %s (%s)", mute.Until.Format(time.RFC822), mute.Reason)
+	}
+
+	// mods aren't slowed down
+	if !p.Role.Can(helpers.ActionDeleteChat) {
+		if wait := chat.SlowModeWait(p.ID, *args.Room); wait != 0 {
+			return fmt.Errorf("Slow mode is on, wait %d seconds before sending another message", int(wait.Seconds())+1)
+		}
+	}
+
+	text, filter := chat.ApplyFilters(*args.Message)
+	if filter != nil {
+		if filter.Action == chat.FilterMute {
+			until := time.Now().Add(time.Duration(filter.MuteFor) * time.Minute)
+			chat.MutePlayer(p.ID, *args.Room, until, "Automatic mute for a filtered message", 0)
+			return fmt.Errorf("You've been muted in this room till %s for sending a filtered message", until.Format(time.RFC822))
+		}
+		return errors.New("Your message contains a filtered word or phrase")
+	}
+
+	message := chat.NewChatMessage(text, *args.Room, p)
 
 	if strings.HasPrefix(*args.Message, "!admin") {
 		chelpers.SendToSlack(*args.Message, p.Name, p.SteamID)
@@ -68,10 +98,48 @@ func (Chat) ChatSend(so *wsevent.Client, args struct {
 
 	message.Save()
 	message.Send()
+	notifyMentions(message, p)
 
 	return emptySuccess
 }
 
+//inRoom returns whether p has either joined, or is spectating the lobby
+//with the chat room room
+func inRoom(p *player.Player, room int) bool {
+	if room == 0 {
+		return true
+	}
+
+	var count int
+	spec := p.IsSpectatingID(uint(room))
+	db.DB.Model(&lobby.LobbySlot{}).Where("lobby_id = ? AND player_id = ?", room, p.ID).Count(&count)
+	return spec || count != 0
+}
+
+//notifyMentions sends notifications to players mentioned in message who
+//can see it: connected players for global chat, players in the lobby otherwise
+func notifyMentions(message *chat.ChatMessage, sender *player.Player) {
+	for _, p := range chat.GetMentioned(message.Message) {
+		if p.ID == sender.ID || p.HasBlocked(sender.ID) {
+			continue
+		}
+		if message.Room == 0 && !sessions.IsConnected(p.SteamID) {
+			continue
+		}
+		if !inRoom(p, message.Room) {
+			continue
+		}
+
+		notification.Send(p.ID, p.SteamID, notification.Mention,
+			fmt.Sprintf("%s mentioned you: %s", sender.Alias(), message.Message),
+			struct {
+				Room      int    `json:"room"`
+				MessageID uint   `json:"messageID"`
+				SteamID   string `json:"steamid"`
+			}{message.Room, message.ID, sender.SteamID})
+	}
+}
+
 func (Chat) ChatDelete(so *wsevent.Client, args struct {
 	ID   *int  `json:"id"`
 	Room *uint `json:"room"`
@@ -93,6 +161,205 @@ func (Chat) ChatDelete(so *wsevent.Client, args struct {
 	message.Deleted = true
 	message.Save()
 	message.Send()
+	chelpers.AuditSocket(so, models.AuditDeleteChat, strconv.Itoa(*args.ID), message.Message, "")
+
+	return emptySuccess
+}
+
+//canMute returns an error if p isn't allowed to mute players in room.
+//Mods can mute players anywhere, and lobby leaders in their lobby's room.
+func canMute(p *player.Player, room int) (bool, error) {
+	if p.Role.Can(helpers.ActionBanChat) {
+		return true, nil
+	}
+	if room > 0 {
+		lob, err := lobby.GetLobbyByID(uint(room))
+		if err != nil {
+			return false, err
+		}
+		if lob.CreatedBySteamID == p.SteamID {
+			return false, nil
+		}
+	}
+	return false, errors.New("You aren't authorized to mute players in this room")
+}
+
+func (Chat) ChatMute(so *wsevent.Client, args struct {
+	Room    *int    `json:"room"`
+	SteamID *string `json:"steamid"`
+	Minutes *int    `json:"minutes"`
+	Reason  *string `json:"reason"`
+}) interface{} {
+	p := chelpers.GetPlayer(so.Token)
+	privileged, err := canMute(p, *args.Room)
+	if err != nil {
+		return err
+	}
+
+	target, err := player.GetPlayerBySteamID(*args.SteamID)
+	if err != nil {
+		return err
+	}
+	if target.ID == p.ID {
+		return errors.New("You can't mute yourself")
+	}
 
+	until := time.Now().Add(time.Duration(*args.Minutes) * time.Minute)
+	if err := chat.MutePlayer(target.ID, *args.Room, until, *args.Reason, p.ID); err != nil {
+		return err
+	}
+
+	if privileged {
+		chelpers.AuditSocket(so, models.AuditMute, target.SteamID, "", fmt.Sprintf("room %d till %s: %s", *args.Room, until.Format(time.RFC822), *args.Reason))
+	}
+	chat.SendNotification(fmt.Sprintf("%s has been muted by %s", target.Alias(), p.Alias()), *args.Room)
 	return emptySuccess
 }
+
+func (Chat) ChatUnmute(so *wsevent.Client, args struct {
+	Room    *int    `json:"room"`
+	SteamID *string `json:"steamid"`
+}) interface{} {
+	p := chelpers.GetPlayer(so.Token)
+	privileged, err := canMute(p, *args.Room)
+	if err != nil {
+		return err
+	}
+
+	target, err := player.GetPlayerBySteamID(*args.SteamID)
+	if err != nil {
+		return err
+	}
+
+	if !privileged {
+		// lobby leaders can only lift their own mutes
+		if err := chat.UnmutePlayerBy(target.ID, *args.Room, p.ID); err != nil {
+			return err
+		}
+		return emptySuccess
+	}
+
+	if err := chat.UnmutePlayer(target.ID, *args.Room); err != nil {
+		return err
+	}
+	chelpers.AuditSocket(so, models.AuditUnmute, target.SteamID, "", fmt.Sprintf("room %d", *args.Room))
+	return emptySuccess
+}
+
+//rate limiting key for direct messages, chat rooms are never negative
+const directRoom = -1
+
+func (Chat) ChatDirectSend(so *wsevent.Client, args struct {
+	SteamID *string `json:"steamid"`
+	Message *string `json:"message"`
+}) interface{} {
+	p := chelpers.GetPlayer(so.Token)
+	if err := sessions.AllowChat(p.SteamID, directRoom, *args.Message); err != nil {
+		return err
+	}
+
+	if banned, until := p.IsBannedWithTime(player.BanChat); banned {
+		ban, _ := p.GetActiveBan(player.BanChat)
+		return fmt.Errorf("You've been banned from chatting till %s (%s)", until.Format(time.RFC822), ban.Reason)
+	}
+	// players muted in global chat can't send direct messages either
+	if mute := chat.GetMute(p.ID, 0); mute != nil {
+		return fmt.Errorf("You've been muted till %s (%s)", mute.Until.Format(time.RFC822), mute.Reason)
+	}
+
+	switch {
+	case len(*args.Message) == 0:
+		return errors.New("Cannot send an empty message")
+
+	case (*args.Message)[0] == '\n':
+		return errors.New("Cannot send messages prefixed with newline")
+
+	case len(*args.Message) > 300:
+		return errors.New("Message too long")
+	}
+
+	text, filter := chat.ApplyFilters(*args.Message)
+	if filter != nil {
+		return errors.New("Your message contains a filtered word or phrase")
+	}
+
+	recipient, err := player.GetPlayerBySteamID(*args.SteamID)
+	if err != nil {
+		return err
+	}
+
+	message, err := chat.SendDirectMessage(p, recipient, text)
+	if err != nil {
+		return err
+	}
+	return newResponse(message)
+}
+
+func (Chat) ChatDirectConversations(so *wsevent.Client, _ struct{}) interface{} {
+	p := chelpers.GetPlayer(so.Token)
+	return newResponse(struct {
+		Conversations []*chat.Conversation `json:"conversations"`
+		Unread        int                  `json:"unread"`
+	}{chat.GetConversations(p.ID), chat.UnreadCount(p.ID)})
+}
+
+func (Chat) ChatDirectHistory(so *wsevent.Client, args struct {
+	SteamID  *string `json:"steamid"`
+	Messages *int    `json:"messages"`
+	Before   uint    `json:"before"` // only messages with an ID lower than this, 0 when not specified in json
+}) interface{} {
+	p := chelpers.GetPlayer(so.Token)
+	other, err := player.GetPlayerBySteamID(*args.SteamID)
+	if err != nil {
+		return err
+	}
+
+	if *args.Messages > 50 || *args.Messages <= 0 {
+		*args.Messages = 50
+	}
+
+	return newResponse(chat.GetConversation(p.ID, other.ID, args.Before, *args.Messages))
+}
+
+func (Chat) ChatDirectRead(so *wsevent.Client, args struct {
+	SteamID *string `json:"steamid"`
+}) interface{} {
+	p := chelpers.GetPlayer(so.Token)
+	other, err := player.GetPlayerBySteamID(*args.SteamID)
+	if err != nil {
+		return err
+	}
+
+	if err := chat.MarkRead(p.ID, other.ID); err != nil {
+		return err
+	}
+	// let the player's other tabs update their unread counts
+	broadcaster.SendMessageSkipIDs(so.ID, p.SteamID, "directMessagesRead", struct {
+		SteamID string `json:"steamid"`
+	}{other.SteamID})
+	return emptySuccess
+}
+
+func (Chat) ChatHistory(so *wsevent.Client, args struct {
+	Room     *int `json:"room"`
+	Messages *int `json:"messages"`
+	Before   uint `json:"before"` // only messages with an ID lower than this, 0 when not specified in json
+}) interface{} {
+	p := chelpers.GetPlayer(so.Token)
+	if *args.Room < 0 {
+		*args.Room = 0
+	}
+	if !inRoom(p, *args.Room) {
+		return errors.New("Player is not in the lobby.")
+	}
+
+	if *args.Messages > 50 || *args.Messages <= 0 {
+		*args.Messages = 50
+	}
+
+	messages, err := chat.GetHistory(*args.Room, p.ID, args.Before, *args.Messages)
+	if err != nil {
+		return err
+	}
+	return newResponse(messages)
+}
@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -19,13 +20,19 @@ import (
 	"github.com/TF2Stadium/Helen/controllers/broadcaster"
 	chelpers "github.com/TF2Stadium/Helen/controllers/controllerhelpers"
 	"github.com/TF2Stadium/Helen/controllers/controllerhelpers/hooks"
+	"github.com/TF2Stadium/Helen/controllers/socket/sessions"
 	db "github.com/TF2Stadium/Helen/database"
 	"github.com/TF2Stadium/Helen/helpers"
+	"github.com/TF2Stadium/Helen/models"
 	"github.com/TF2Stadium/Helen/models/chat"
+	"github.com/TF2Stadium/Helen/models/demo"
 	"github.com/TF2Stadium/Helen/models/gameserver"
 	"github.com/TF2Stadium/Helen/models/lobby"
 	"github.com/TF2Stadium/Helen/models/lobby/format"
+	"github.com/TF2Stadium/Helen/models/lobby/timeline"
+	"github.com/TF2Stadium/Helen/models/notification"
 	"github.com/TF2Stadium/Helen/models/player"
+	"github.com/TF2Stadium/Helen/models/region"
 	"github.com/TF2Stadium/Helen/models/rpc"
 	"github.com/TF2Stadium/Helen/routes/socket"
 	"github.com/TF2Stadium/servemetf"
@@ -34,6 +41,18 @@ import (
 
 type Lobby struct{}
 
+//auditLobby records an action done on lob by someone other than its leader,
+//who needed a privileged role to do it
+func auditLobby(so *wsevent.Client, lob *lobby.Lobby, action, target, before, after string) {
+	if so.Token.Claims.(*chelpers.TF2StadiumClaims).SteamID != lob.CreatedBySteamID {
+		chelpers.AuditSocket(so, action, target, before, after)
+	}
+}
+
+func lobbyTarget(lob *lobby.Lobby) string {
+	return strconv.FormatUint(uint64(lob.ID), 10)
+}
+
 func (Lobby) Name(s string) string {
 	return string((s[0])+32) + s[1:]
 }
@@ -104,6 +123,7 @@ func (Lobby) LobbyCreate(so *wsevent.Client, args struct {
 	TwitchWhitelistSubscribers bool `json:"twitchWhitelistSubs"`
 	TwitchWhitelistFollowers   bool `json:"twitchWhitelistFollows"`
 	RegionLock                 bool `json:"regionLock"`
+	RegionLockPing             int  `json:"regionLockPing"` // lock by ping instead of region, 0 when not specified
 
 	Requirements *struct {
 		Classes map[string]Requirement `json:"classes,omitempty"`
@@ -121,8 +141,9 @@ func (Lobby) LobbyCreate(so *wsevent.Client, args struct {
 		return fmt.Errorf("You've been banned from creating lobbies till %s (%s)", until.Format(time.RFC822), ban.Reason)
 	}
 
-	if p.HasCreatedLobby() {
-		if p.Role != helpers.RoleAdmin && p.Role != helpers.RoleMod {
+	extraLobby := p.HasCreatedLobby()
+	if extraLobby {
+		if !p.Role.Can(helpers.ActionManageLobbies) {
 			return errors.New("You have already created a lobby.")
 		}
 	}
@@ -139,6 +160,10 @@ func (Lobby) LobbyCreate(so *wsevent.Client, args struct {
 		}
 	}
 
+	if args.RegionLockPing < 0 {
+		return errors.New("Invalid ping limit")
+	}
+
 	if *args.ServerType == "serveme" {
 		if args.Serveme == nil {
 			return errors.New("No serveme info given.")
@@ -249,14 +274,12 @@ func (Lobby) LobbyCreate(so *wsevent.Client, args struct {
 	}
 
 	lob.RegionLock = args.RegionLock
+	lob.RegionLockPing = args.RegionLockPing
 	lob.CreatedBySteamID = p.SteamID
-	lob.RegionCode, lob.RegionName = helpers.GetRegion(*args.Server)
+	lob.RegionCode, lob.RegionName = region.GetRegion(*args.Server)
 	if (lob.RegionCode == "" || lob.RegionName == "") && config.Constants.GeoIP {
 		if reservation.ID != 0 {
-			err := context.Delete(reservation.ID, p.SteamID)
-			for err != nil {
-				err = context.Delete(reservation.ID, p.SteamID)
-			}
+			go lobby.DeleteReservation(context, reservation.ID, p.SteamID)
 		} else if *args.ServerType == "storedServer" {
 			gameserver.PutStoredServer(*args.Server)
 		}
@@ -266,10 +289,7 @@ func (Lobby) LobbyCreate(so *wsevent.Client, args struct {
 
 	if lobby.MapRegionFormatExists(lob.MapName, lob.RegionCode, lob.Type) {
 		if reservation.ID != 0 {
-			err := context.Delete(reservation.ID, p.SteamID)
-			for err != nil {
-				err = context.Delete(reservation.ID, p.SteamID)
-			}
+			go lobby.DeleteReservation(context, reservation.ID, p.SteamID)
 		} else if *args.ServerType == "storedServer" {
 			gameserver.PutStoredServer(*args.Server)
 		}
@@ -284,36 +304,6 @@ func (Lobby) LobbyCreate(so *wsevent.Client, args struct {
 	lob.Save()
 	lob.CreateLock()
 
-	if *args.ServerType == "serveme" {
-		now := time.Now()
-
-		for {
-			status, err := context.Status(reservation.ID, p.SteamID)
-			if err != nil {
-				logrus.Error(err)
-			}
-			if status == "ready" {
-				break
-			}
-
-			time.Sleep(10 * time.Second)
-			if time.Since(now) >= 3*time.Minute {
-				lob.Delete()
-				return errors.New("Couldn't get Serveme reservation, try another server.")
-			}
-		}
-
-		lob.ServemeCheck(context)
-	}
-
-	err := lob.SetupServer()
-	if err != nil { //lobby setup failed, delete lobby and corresponding server record
-		lob.Delete()
-		return err
-	}
-
-	lob.SetState(lobby.Waiting)
-
 	if args.Requirements != nil {
 		for class, requirement := range (*args.Requirements).Classes {
 			if requirement.Restricted.Blu {
@@ -348,9 +338,26 @@ func (Lobby) LobbyCreate(so *wsevent.Client, args struct {
 		}
 	}
 
+	if *args.ServerType == "serveme" {
+		// the lobby stays in Initializing till the reservation has started,
+		// the creator gets lobbyReservationStatus events till then
+		lob.AwaitReservation(context)
+	} else {
+		err := lob.SetupServer()
+		if err != nil { //lobby setup failed, delete lobby and corresponding server record
+			lob.Delete()
+			return err
+		}
+
+		lob.SetState(lobby.Waiting)
+		lobby.BroadcastLobbyList()
+	}
+
 	chat.NewBotMessage(fmt.Sprintf("Lobby created by %s", p.Alias()), int(lob.ID)).Send()
+	if extraLobby {
+		chelpers.AuditSocket(so, models.AuditCreateExtraLobby, lobbyTarget(lob), "", "")
+	}
 
-	lobby.BroadcastLobbyList()
 	return newResponse(
 		struct {
 			ID uint `json:"id"`
@@ -364,7 +371,7 @@ func (Lobby) LobbyServerReset(so *wsevent.Client, args struct {
 	player := chelpers.GetPlayer(so.Token)
 	lob, tperr := lobby.GetLobbyByID(*args.ID)
 
-	if player.SteamID != lob.CreatedBySteamID && (player.Role != helpers.RoleAdmin && player.Role != helpers.RoleMod) {
+	if player.SteamID != lob.CreatedBySteamID && !player.Role.Can(helpers.ActionManageLobbies) {
 		return errors.New("You are not authorized to reset server.")
 	}
 
@@ -379,6 +386,7 @@ func (Lobby) LobbyServerReset(so *wsevent.Client, args struct {
 	if err := rpc.ReExecConfig(lob.ID, false); err != nil {
 		return err
 	}
+	auditLobby(so, lob, models.AuditResetServer, lobbyTarget(lob), "", "")
 
 	return emptySuccess
 }
@@ -425,7 +433,7 @@ func (Lobby) LobbyClose(so *wsevent.Client, args struct {
 		return tperr
 	}
 
-	if player.SteamID != lob.CreatedBySteamID && !(player.Role == helpers.RoleAdmin || player.Role == helpers.RoleMod) {
+	if player.SteamID != lob.CreatedBySteamID && !player.Role.Can(helpers.ActionCloseLobby) {
 		return errors.New("Player not authorized to close lobby.")
 
 	}
@@ -435,6 +443,7 @@ func (Lobby) LobbyClose(so *wsevent.Client, args struct {
 	}
 
 	lob.Close(true, false)
+	auditLobby(so, lob, models.AuditCloseLobby, lobbyTarget(lob), "", "")
 
 	notify := fmt.Sprintf("Lobby closed by %s", player.Alias())
 	chat.SendNotification(notify, int(lob.ID))
@@ -461,6 +470,9 @@ func (Lobby) LobbyJoin(so *wsevent.Client, args struct {
 	Class    *string `json:"class"`
 	Team     *string `json:"team" valid:"red,blu"`
 	Password *string `json:"password" empty:"-"`
+	// ping to the lobby's server measured by the client (in ms),
+	// 0 when not specified in json
+	Ping int `json:"ping"`
 }) interface{} {
 
 	p := chelpers.GetPlayer(so.Token)
@@ -492,8 +504,14 @@ func (Lobby) LobbyJoin(so *wsevent.Client, args struct {
 	}
 
 	if lob.RegionLock {
-		region, _ := helpers.GetRegion(chelpers.GetIPAddr(so.Request))
-		if region != lob.RegionCode {
+		if lob.RegionLockPing != 0 {
+			if args.Ping <= 0 {
+				return errors.New("This lobby is locked by ping, your client needs to measure the ping to the server first.")
+			}
+			if args.Ping > lob.RegionLockPing {
+				return fmt.Errorf("This lobby is locked to players with a ping below %dms to the server (yours is %dms).", lob.RegionLockPing, args.Ping)
+			}
+		} else if code, _ := region.GetRegion(chelpers.GetIPAddr(so.Request)); code != lob.RegionCode {
 			return errors.New("This lobby is region locked.")
 		}
 	}
@@ -509,6 +527,11 @@ func (Lobby) LobbyJoin(so *wsevent.Client, args struct {
 		return tperr
 	}
 
+	blocked := blockedInLobby(p, lob)
+	if len(blocked) != 0 && p.GetSetting("blockedInLobby") == "prevent" {
+		return fmt.Errorf("This lobby has players you've blocked: %s", strings.Join(blocked, ", "))
+	}
+
 	if prevId, _ := p.GetLobbyID(false); prevId != 0 && !sameLobby {
 		lob, _ := lobby.GetLobbyByID(prevId)
 		hooks.AfterLobbyLeave(lob, p, false, false)
@@ -522,6 +545,11 @@ func (Lobby) LobbyJoin(so *wsevent.Client, args struct {
 
 	if !sameLobby {
 		hooks.AfterLobbyJoin(so, lob, p)
+		if len(blocked) != 0 {
+			so.EmitJSON(helpers.NewRequest("lobbyBlockedPlayers", struct {
+				Players []string `json:"players"`
+			}{blocked}))
+		}
 	}
 
 	playersCnt := lob.GetPlayerNumber()
@@ -577,6 +605,30 @@ func (Lobby) LobbyJoin(so *wsevent.Client, args struct {
 	return emptySuccess
 }
 
+//blockedInLobby returns the names of the players in lob that p has blocked
+func blockedInLobby(p *player.Player, lob *lobby.Lobby) []string {
+	ids := p.GetBlockedIDs()
+	if len(ids) == 0 {
+		return nil
+	}
+
+	blocked := make(map[uint]bool)
+	for _, id := range ids {
+		blocked[id] = true
+	}
+
+	var names []string
+	for _, slot := range lob.GetAllSlots() {
+		if blocked[slot.PlayerID] {
+			other, err := player.GetPlayerByID(slot.PlayerID)
+			if err == nil {
+				names = append(names, other.Alias())
+			}
+		}
+	}
+	return names
+}
+
 //get list of unready players, remove them from lobby (and add them as spectators)
 //plus, call the after lobby leave hook for each player removed
 func removeUnreadyPlayers(lobby *lobby.Lobby) {
@@ -611,7 +663,7 @@ func (Lobby) LobbySpectatorJoin(so *wsevent.Client, args struct {
 			//a socket should only spectate one lobby, remove socket from
 			//any other lobby room
 			//multiple sockets from one player can spectatte multiple lobbies
-			socket.AuthServer.Leave(so, fmt.Sprintf("%d_public", id))
+			sessions.LeaveRoom(socket.AuthServer, so, fmt.Sprintf("%d_public", id))
 		}
 	}
 
@@ -671,7 +723,7 @@ func playerCanKick(lobbyId uint, steamId string) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	if steamId != lob.CreatedBySteamID && player.Role != helpers.RoleAdmin {
+	if steamId != lob.CreatedBySteamID && !player.Role.Can(helpers.ActionKickFromLobby) {
 		return false, errors.New("Not authorized to kick players")
 	}
 	return true, nil
@@ -697,10 +749,15 @@ func (Lobby) LobbyKick(so *wsevent.Client, args struct {
 		return tperr
 	}
 
+	timeline.LogBy(lob.ID, timeline.Kick, player, chelpers.GetPlayer(so.Token), "")
+	auditLobby(so, lob, models.AuditKick, player.SteamID, "", "lobby "+lobbyTarget(lob))
 	hooks.AfterLobbyLeave(lob, player, true, false)
 
-	// broadcaster.SendMessage(steamId, "sendNotification",
-	// 	fmt.Sprintf(`{"notification": "You have been removed from Lobby #%d"}`, *args.Id))
+	notification.Send(player.ID, player.SteamID, notification.Kick,
+		fmt.Sprintf("You have been removed from Lobby #%d", lob.ID), struct {
+			LobbyID uint   `json:"lobbyID"`
+			SteamID string `json:"steamid"`
+		}{lob.ID, selfSteamId})
 
 	return emptySuccess
 }
@@ -726,11 +783,16 @@ func (Lobby) LobbyBan(so *wsevent.Client, args struct {
 	}
 
 	lob.BanPlayer(player)
+	timeline.LogBy(lob.ID, timeline.Ban, player, chelpers.GetPlayer(so.Token), "")
+	auditLobby(so, lob, models.AuditLobbyBan, player.SteamID, "", "lobby "+lobbyTarget(lob))
 
 	hooks.AfterLobbyLeave(lob, player, true, false)
 
-	// broadcaster.SendMessage(steamId, "sendNotification",
-	// 	fmt.Sprintf(`{"notification": "You have been removed from Lobby #%d"}`, *args.Id))
+	notification.Send(player.ID, player.SteamID, notification.Kick,
+		fmt.Sprintf("You have been removed and banned from Lobby #%d", lob.ID), struct {
+			LobbyID uint   `json:"lobbyID"`
+			SteamID string `json:"steamid"`
+		}{lob.ID, selfSteamId})
 
 	return emptySuccess
 }
@@ -803,6 +865,7 @@ func (Lobby) LobbyChangeOwner(so *wsevent.Client, args struct {
 
 	lob.CreatedBySteamID = player2.SteamID
 	lob.Save()
+	timeline.LogBy(lob.ID, timeline.OwnerChange, player2, player1, "")
 	lobby.BroadcastLobby(lob)
 	lobby.BroadcastLobbyList()
 	chat.NewBotMessage(fmt.Sprintf("Lobby leader changed to %s", player2.Alias()), int(*args.ID)).Send()
@@ -891,7 +954,7 @@ func (Lobby) LobbySetTeamName(so *wsevent.Client, args struct {
 		return err
 	}
 
-	if player.SteamID != lob.CreatedBySteamID && (player.Role != helpers.RoleAdmin && player.Role != helpers.RoleMod) {
+	if player.SteamID != lob.CreatedBySteamID && !player.Role.Can(helpers.ActionManageLobbies) {
 		return errors.New("You aren't authorized to do this.")
 	}
 
@@ -899,13 +962,17 @@ func (Lobby) LobbySetTeamName(so *wsevent.Client, args struct {
 		return errors.New("team name must be between 1-12 characters long.")
 	}
 
+	var oldName string
 	if args.Team == "red" {
+		oldName = lob.RedTeamName
 		lob.RedTeamName = args.NewName
 	} else if args.Team == "blu" {
+		oldName = lob.BluTeamName
 		lob.BluTeamName = args.NewName
 	} else {
 		return errors.New("team must be red or blu.")
 	}
+	auditLobby(so, lob, models.AuditSetTeamName, lobbyTarget(lob), args.Team+": "+oldName, args.Team+": "+args.NewName)
 
 	lob.Save()
 	lobby.BroadcastLobby(lob)
@@ -922,10 +989,11 @@ func (Lobby) LobbyRemoveTwitchRestriction(so *wsevent.Client, args struct {
 		return err
 	}
 
-	if player.SteamID != lob.CreatedBySteamID && (player.Role != helpers.RoleAdmin && player.Role != helpers.RoleMod) {
+	if player.SteamID != lob.CreatedBySteamID && !player.Role.Can(helpers.ActionManageLobbies) {
 		return errors.New("You aren't authorized to do this.")
 	}
 
+	auditLobby(so, lob, models.AuditRemoveRestrict, lobbyTarget(lob), "twitch: "+lob.TwitchChannel, "")
 	lob.TwitchChannel = ""
 	lob.Save()
 
@@ -945,10 +1013,11 @@ func (Lobby) LobbyRemoveSteamRestriction(so *wsevent.Client, args struct {
 		return err
 	}
 
-	if player.SteamID != lob.CreatedBySteamID && (player.Role != helpers.RoleAdmin && player.Role != helpers.RoleMod) {
+	if player.SteamID != lob.CreatedBySteamID && !player.Role.Can(helpers.ActionManageLobbies) {
 		return errors.New("You aren't authorized to do this.")
 	}
 
+	auditLobby(so, lob, models.AuditRemoveRestrict, lobbyTarget(lob), "steam group: "+lob.PlayerWhitelist, "")
 	lob.PlayerWhitelist = ""
 	lob.Save()
 
@@ -968,10 +1037,11 @@ func (Lobby) LobbyRemoveRegionLock(so *wsevent.Client, args struct {
 		return err
 	}
 
-	if player.SteamID != lob.CreatedBySteamID && (player.Role != helpers.RoleAdmin && player.Role != helpers.RoleMod) {
+	if player.SteamID != lob.CreatedBySteamID && !player.Role.Can(helpers.ActionManageLobbies) {
 		return errors.New("You aren't authorized to do this.")
 	}
 
+	auditLobby(so, lob, models.AuditRemoveRestrict, lobbyTarget(lob), "region lock", "")
 	lob.RegionLock = false
 	lob.Save()
 
@@ -992,16 +1062,36 @@ func (Lobby) LobbyShuffle(so *wsevent.Client, args struct {
 		return err
 	}
 
-	if player.SteamID != lob.CreatedBySteamID && (player.Role != helpers.RoleAdmin && player.Role != helpers.RoleMod) {
+	if player.SteamID != lob.CreatedBySteamID && !player.Role.Can(helpers.ActionManageLobbies) {
 		return errors.New("You aren't authorized to shuffle this lobby.")
 	}
 
 	if err = lob.ShuffleAllSlots(); err != nil {
 		return err
 	}
+	auditLobby(so, lob, models.AuditShuffle, lobbyTarget(lob), "", "")
 
 	room := fmt.Sprintf("%s_private", hooks.GetLobbyRoom(args.Id))
 	broadcaster.SendMessageToRoom(room, "lobbyShuffled", args)
 	chat.NewBotMessage(fmt.Sprintf("Lobby shuffled by %s", player.Alias()), int(args.Id)).Send()
 	return emptySuccess
 }
+
+func (Lobby) LobbyDemos(so *wsevent.Client, args struct {
+	ID *uint `json:"id"`
+}) interface{} {
+	return newResponse(demo.GetLobbyDemos(*args.ID))
+}
+
+func (Lobby) LobbyTimeline(so *wsevent.Client, args struct {
+	ID *uint `json:"id"`
+}) interface{} {
+	if _, err := lobby.GetLobbyByID(*args.ID); err != nil {
+		return err
+	}
+
+	if chelpers.CheckPrivilege(so, helpers.ActionViewLogs) != nil {
+		return newResponse(timeline.GetPublic(*args.ID))
+	}
+	return newResponse(timeline.Get(*args.ID))
+}
@@ -2,16 +2,23 @@ package handler
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
 	"sync"
 	"time"
 
+	"github.com/TF2Stadium/Helen/controllers/broadcaster"
 	chelpers "github.com/TF2Stadium/Helen/controllers/controllerhelpers"
 	"github.com/TF2Stadium/Helen/controllers/controllerhelpers/hooks"
 	"github.com/TF2Stadium/Helen/controllers/socket/sessions"
 	db "github.com/TF2Stadium/Helen/database"
 	"github.com/TF2Stadium/Helen/helpers"
+	"github.com/TF2Stadium/Helen/models/apitoken"
+	"github.com/TF2Stadium/Helen/models/chat"
+	"github.com/TF2Stadium/Helen/models/demo"
 	"github.com/TF2Stadium/Helen/models/lobby"
+	"github.com/TF2Stadium/Helen/models/lobby/timeline"
+	"github.com/TF2Stadium/Helen/models/notification"
 	"github.com/TF2Stadium/Helen/models/player"
 	"github.com/TF2Stadium/Helen/models/rpc"
 	"github.com/TF2Stadium/wsevent"
@@ -128,6 +135,13 @@ func (Player) PlayerSettingsSet(so *wsevent.Client, args struct {
 			player.SetMumbleUsername(lob.Type, slot)
 			lobby.BroadcastLobby(lob)
 		}
+	case "blockedInLobby":
+		// what happens when joining a lobby with blocked players
+		if *args.Value != "warn" && *args.Value != "prevent" {
+			return errors.New("blockedInLobby has to be either warn or prevent")
+		}
+
+		player.SetSetting(*args.Key, *args.Value)
 	default:
 		player.SetSetting(*args.Key, *args.Value)
 	}
@@ -243,3 +257,260 @@ func (Player) PlayerRecentLobbies(so *wsevent.Client, args struct {
 
 	return newResponse(lobby.DecorateLobbyListData(lobbies, true))
 }
+
+func (Player) PlayerDemos(so *wsevent.Client, args struct {
+	SteamID *string `json:"steamid"`
+	Demos   *int    `json:"demos"`
+	Before  uint    `json:"before"` // only demos with an ID lower than this, 0 when not specified in json
+}) interface{} {
+	var p *player.Player
+
+	if *args.SteamID != "" {
+		var err error
+		p, err = player.GetPlayerBySteamID(*args.SteamID)
+		if err != nil {
+			return err
+		}
+	} else {
+		p = chelpers.GetPlayer(so.Token)
+	}
+
+	if *args.Demos > 50 {
+		*args.Demos = 50
+	}
+
+	return newResponse(demo.GetPlayerDemos(p.ID, args.Before, *args.Demos))
+}
+
+func (Player) PlayerTokenCreate(so *wsevent.Client, args struct {
+	Name   *string  `json:"name"`
+	Scopes []string `json:"scopes"`
+}) interface{} {
+	player := chelpers.GetPlayer(so.Token)
+
+	token, raw, err := apitoken.New(player.ID, *args.Name, args.Scopes)
+	if err != nil {
+		return err
+	}
+
+	// the token is only sent once, it can't be retrieved later
+	return newResponse(struct {
+		*apitoken.Token
+		Secret string `json:"token"`
+	}{token, raw})
+}
+
+func (Player) PlayerTokens(so *wsevent.Client, _ struct{}) interface{} {
+	player := chelpers.GetPlayer(so.Token)
+	return newResponse(apitoken.GetPlayerTokens(player.ID))
+}
+
+func (Player) PlayerTokenRevoke(so *wsevent.Client, args struct {
+	ID *uint `json:"id"`
+}) interface{} {
+	player := chelpers.GetPlayer(so.Token)
+
+	if err := apitoken.Revoke(player.ID, *args.ID); err != nil {
+		return err
+	}
+	return emptySuccess
+}
+
+func (Player) PlayerTokenUsage(so *wsevent.Client, args struct {
+	ID *uint `json:"id"`
+}) interface{} {
+	player := chelpers.GetPlayer(so.Token)
+
+	for _, token := range apitoken.GetPlayerTokens(player.ID) {
+		if token.ID == *args.ID {
+			return newResponse(apitoken.GetUsage(token.ID, 100))
+		}
+	}
+	return errors.New("No such token")
+}
+
+func (Player) PlayerSessions(so *wsevent.Client, _ struct{}) interface{} {
+	p := chelpers.GetPlayer(so.Token)
+	current := so.Token.Claims.(*chelpers.TF2StadiumClaims).SessionID
+
+	type session struct {
+		*player.Session
+		Current bool `json:"current"`
+	}
+
+	list := []session{}
+	for _, s := range p.GetSessions() {
+		list = append(list, session{s, s.ID == current})
+	}
+	return newResponse(list)
+}
+
+func (Player) PlayerSessionRevoke(so *wsevent.Client, args struct {
+	ID *uint `json:"id"`
+}) interface{} {
+	player := chelpers.GetPlayer(so.Token)
+
+	if err := player.RevokeSession(*args.ID); err != nil {
+		return err
+	}
+	chelpers.CloseSessionSockets(player.SteamID, *args.ID)
+	return emptySuccess
+}
+
+func (Player) PlayerLogoutEverywhere(so *wsevent.Client, _ struct{}) interface{} {
+	chelpers.LogoutEverywhere(chelpers.GetPlayer(so.Token))
+	return emptySuccess
+}
+
+func (Player) PlayerReport(so *wsevent.Client, args struct {
+	SteamID  *string `json:"steamid"`
+	Category *string `json:"category"`
+	LobbyID  *uint   `json:"lobbyID"`
+	ChatIDs  []uint  `json:"chatIDs"`
+	Details  *string `json:"details"`
+}) interface{} {
+	reporter := chelpers.GetPlayer(so.Token)
+	target, err := player.GetPlayerBySteamID(*args.SteamID)
+	if err != nil {
+		return errors.New("No player with the given SteamID")
+	}
+
+	if *args.LobbyID != 0 {
+		if _, err := lobby.GetLobbyByID(*args.LobbyID); err != nil {
+			return err
+		}
+	}
+	if len(*args.Details) > 500 {
+		return errors.New("Details can't be longer than 500 characters")
+	}
+
+	// only messages the reported player sent can be attached
+	if len(args.ChatIDs) != 0 {
+		var count int
+		db.DB.Model(&chat.ChatMessage{}).Where("id IN (?) AND player_id = ?", args.ChatIDs, target.ID).Count(&count)
+		if count != len(args.ChatIDs) {
+			return errors.New("Invalid chat messages")
+		}
+	}
+
+	report, err := reporter.FileReport(target, player.ReportCategory(*args.Category), *args.LobbyID, args.ChatIDs, *args.Details)
+	if err != nil {
+		return err
+	}
+
+	if report.LobbyID != 0 {
+		// the reporter is only shown to mods, on the report itself
+		timeline.Log(report.LobbyID, timeline.Report, target, string(report.Category))
+	}
+	chelpers.SendToSlack(fmt.Sprintf("reported %s (%s) for %s: %s", target.Name, target.SteamID, report.Category, report.Details),
+		reporter.Name, reporter.SteamID)
+
+	return newResponse(report)
+}
+
+func (Player) PlayerReports(so *wsevent.Client, _ struct{}) interface{} {
+	return newResponse(chelpers.GetPlayer(so.Token).GetReportsFiled())
+}
+
+func (Player) PlayerBans(so *wsevent.Client, _ struct{}) interface{} {
+	p := chelpers.GetPlayer(so.Token)
+	bans, _ := p.GetActiveBans()
+
+	appealed := make(map[uint]*player.BanAppeal)
+	for _, appeal := range p.GetAppeals() {
+		appealed[appeal.BanID] = appeal
+	}
+
+	type ban struct {
+		ID     uint              `json:"id"`
+		Type   string            `json:"type"`
+		Until  time.Time         `json:"until"`
+		Reason string            `json:"reason"`
+		Appeal *player.BanAppeal `json:"appeal"`
+	}
+
+	list := make([]ban, len(bans))
+	for i, b := range bans {
+		list[i] = ban{b.ID, b.Type.String(), b.Until, b.Reason, appealed[b.ID]}
+	}
+	return newResponse(list)
+}
+
+func (Player) PlayerBanAppeal(so *wsevent.Client, args struct {
+	ID      *uint   `json:"id"`
+	Message *string `json:"message"`
+}) interface{} {
+	p := chelpers.GetPlayer(so.Token)
+
+	appeal, err := p.AppealBan(*args.ID, *args.Message)
+	if err != nil {
+		return err
+	}
+
+	chelpers.SendToSlack(fmt.Sprintf("appealed ban #%d: %s", appeal.BanID, appeal.Message), p.Name, p.SteamID)
+	return newResponse(appeal)
+}
+
+func (Player) PlayerBlock(so *wsevent.Client, args struct {
+	SteamID *string `json:"steamid"`
+}) interface{} {
+	p := chelpers.GetPlayer(so.Token)
+	other, err := player.GetPlayerBySteamID(*args.SteamID)
+	if err != nil {
+		return err
+	}
+
+	if err := p.Block(other); err != nil {
+		return err
+	}
+	return emptySuccess
+}
+
+func (Player) PlayerUnblock(so *wsevent.Client, args struct {
+	SteamID *string `json:"steamid"`
+}) interface{} {
+	p := chelpers.GetPlayer(so.Token)
+	other, err := player.GetPlayerBySteamID(*args.SteamID)
+	if err != nil {
+		return err
+	}
+
+	if err := p.Unblock(other); err != nil {
+		return err
+	}
+	return emptySuccess
+}
+
+func (Player) PlayerBlocks(so *wsevent.Client, _ struct{}) interface{} {
+	return newResponse(chelpers.GetPlayer(so.Token).GetBlocked())
+}
+
+func (Player) PlayerNotifications(so *wsevent.Client, args struct {
+	Notifications *int `json:"notifications"`
+	Before        uint `json:"before"` // only notifications with an ID lower than this, 0 when not specified in json
+}) interface{} {
+	p := chelpers.GetPlayer(so.Token)
+	if *args.Notifications > 50 || *args.Notifications <= 0 {
+		*args.Notifications = 50
+	}
+
+	return newResponse(struct {
+		Notifications []*notification.Notification `json:"notifications"`
+		Unread        int                          `json:"unread"`
+	}{notification.Get(p.ID, args.Before, *args.Notifications), notification.UnreadCount(p.ID)})
+}
+
+func (Player) PlayerNotificationsRead(so *wsevent.Client, args struct {
+	IDs []uint `json:"ids"` // all notifications when empty
+}) interface{} {
+	p := chelpers.GetPlayer(so.Token)
+	if err := notification.MarkRead(p.ID, args.IDs); err != nil {
+		return err
+	}
+
+	// let the player's other tabs update their unread counts
+	broadcaster.SendMessageSkipIDs(so.ID, p.SteamID, "notificationsUnread", struct {
+		Unread int `json:"unread"`
+	}{notification.UnreadCount(p.ID)})
+	return emptySuccess
+}
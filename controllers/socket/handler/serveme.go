@@ -8,6 +8,7 @@ import (
 	chelpers "github.com/TF2Stadium/Helen/controllers/controllerhelpers"
 	"github.com/TF2Stadium/Helen/helpers"
 	"github.com/TF2Stadium/Helen/models/gameserver"
+	"github.com/TF2Stadium/Helen/models/lobby"
 	"github.com/TF2Stadium/servemetf"
 	"github.com/TF2Stadium/wsevent"
 )
@@ -56,3 +57,17 @@ func (Serveme) GetStoredServers(so *wsevent.Client, _ struct{}) interface{} {
 	servers := gameserver.GetAvailableServers()
 	return newResponse(servers)
 }
+
+func (Serveme) GetReservationStatus(so *wsevent.Client, args struct {
+	ID *uint `json:"id"`
+}) interface{} {
+	status, ok := lobby.GetReservationStatus(*args.ID)
+	if !ok {
+		return errors.New("No reservation is being setup for this lobby.")
+	}
+
+	return newResponse(struct {
+		ID     uint                    `json:"id"`
+		Status lobby.ReservationStatus `json:"status"`
+	}{*args.ID, status})
+}
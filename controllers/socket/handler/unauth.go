@@ -41,7 +41,7 @@ func (Unauth) LobbySpectatorLeave(so *wsevent.Client, args struct {
 
 	id, ok := sessions.GetSpectating(so.ID)
 	if ok {
-		socket.UnauthServer.Leave(so, fmt.Sprintf("%d_public", id))
+		sessions.LeaveRoom(socket.UnauthServer, so, fmt.Sprintf("%d_public", id))
 		sessions.RemoveSpectator(so.ID)
 	}
 
@@ -0,0 +1,77 @@
+package socket
+
+import (
+	"errors"
+	"reflect"
+
+	chelpers "github.com/TF2Stadium/Helen/controllers/controllerhelpers"
+	"github.com/TF2Stadium/Helen/models/apitoken"
+	"github.com/TF2Stadium/wsevent"
+)
+
+//scopes maps requests to the scope API tokens need to make them.
+//Requests not listed here can't be made with API tokens at all,
+//and requests with an empty scope can be made with any token.
+var scopes = map[string]string{
+	"requestLobbyListData": "",
+	"lobbyDemos":           "",
+
+	"playerProfile":       apitoken.ScopeReadProfile,
+	"playerRecentLobbies": apitoken.ScopeReadProfile,
+	"playerDemos":         apitoken.ScopeReadProfile,
+
+	"lobbyJoin":           apitoken.ScopeJoinLobby,
+	"lobbyLeave":          apitoken.ScopeJoinLobby,
+	"lobbySpectatorJoin":  apitoken.ScopeJoinLobby,
+	"lobbySpectatorLeave": apitoken.ScopeJoinLobby,
+	"playerReady":         apitoken.ScopeJoinLobby,
+	"playerNotReady":      apitoken.ScopeJoinLobby,
+
+	"lobbyCreate":          apitoken.ScopeCreateLobby,
+	"lobbyClose":           apitoken.ScopeCreateLobby,
+	"serverVerify":         apitoken.ScopeCreateLobby,
+	"getServemeServers":    apitoken.ScopeCreateLobby,
+	"getStoredServers":     apitoken.ScopeCreateLobby,
+	"getReservationStatus": apitoken.ScopeCreateLobby,
+
+	"chatSend": apitoken.ScopeChat,
+}
+
+var errScope = errors.New("Your API token doesn't have the scope needed for this request")
+
+//registerScoped registers rcvr's handlers like (*wsevent.Server).Register,
+//but checks the client's API token (if any) has the needed scope first
+func registerScoped(server *wsevent.Server, rcvr wsevent.Receiver) {
+	rvalue := reflect.ValueOf(rcvr)
+	rtype := reflect.TypeOf(rcvr)
+
+	for i := 0; i < rvalue.NumMethod(); i++ {
+		name := rtype.Method(i).Name
+		method := rvalue.Method(i)
+		if name == "Name" || method.Type().NumIn() != 2 || method.Type().NumOut() != 1 {
+			continue
+		}
+
+		request := rcvr.Name(name)
+		server.On(request, scoped(request, method))
+	}
+}
+
+func scoped(request string, method reflect.Value) interface{} {
+	scope, allowed := scopes[request]
+
+	return reflect.MakeFunc(method.Type(), func(args []reflect.Value) []reflect.Value {
+		so := args[0].Interface().(*wsevent.Client)
+		claims := so.Token.Claims.(*chelpers.TF2StadiumClaims)
+
+		if claims.TokenID != 0 {
+			if !allowed || !claims.HasScope(scope) {
+				var err interface{} = errScope
+				return []reflect.Value{reflect.ValueOf(&err).Elem()}
+			}
+			apitoken.LogUsage(claims.TokenID, request, chelpers.GetIPAddr(so.Request))
+		}
+
+		return method.Call(args)
+	}).Interface()
+}
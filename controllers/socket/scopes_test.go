@@ -0,0 +1,61 @@
+package socket
+
+import (
+	"reflect"
+	"testing"
+
+	chelpers "github.com/TF2Stadium/Helen/controllers/controllerhelpers"
+	"github.com/TF2Stadium/Helen/controllers/socket/handler"
+	"github.com/TF2Stadium/Helen/models/apitoken"
+	"github.com/TF2Stadium/wsevent"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func requestNames(rcvrs ...wsevent.Receiver) map[string]bool {
+	names := make(map[string]bool)
+	for _, rcvr := range rcvrs {
+		rtype := reflect.TypeOf(rcvr)
+		for i := 0; i < rtype.NumMethod(); i++ {
+			names[rcvr.Name(rtype.Method(i).Name)] = true
+		}
+	}
+	return names
+}
+
+func TestScopesExist(t *testing.T) {
+	names := requestNames(handler.Global{}, handler.Lobby{}, handler.Player{},
+		handler.Chat{}, handler.Serveme{}, handler.Mumble{})
+
+	for request := range scopes {
+		assert.True(t, names[request], "%s isn't a request", request)
+	}
+}
+
+func TestScoped(t *testing.T) {
+	called := false
+	f := func(so *wsevent.Client, _ struct{}) interface{} {
+		called = true
+		return nil
+	}
+	client := func(claims *chelpers.TF2StadiumClaims) *wsevent.Client {
+		return &wsevent.Client{Token: &jwt.Token{Claims: claims}}
+	}
+
+	// logins through steam can make any request
+	wrapped := scoped("playerSettingsSet", reflect.ValueOf(f)).(func(*wsevent.Client, struct{}) interface{})
+	assert.Nil(t, wrapped(client(&chelpers.TF2StadiumClaims{}), struct{}{}))
+	assert.True(t, called)
+
+	// requests not in scopes can't be made with tokens
+	called = false
+	reply := wrapped(client(&chelpers.TF2StadiumClaims{TokenID: 1, Scopes: []string{apitoken.ScopeChat}}), struct{}{})
+	assert.Equal(t, errScope, reply.(error))
+	assert.False(t, called)
+
+	// tokens need the request's scope
+	wrapped = scoped("lobbyCreate", reflect.ValueOf(f)).(func(*wsevent.Client, struct{}) interface{})
+	reply = wrapped(client(&chelpers.TF2StadiumClaims{TokenID: 1, Scopes: []string{apitoken.ScopeJoinLobby}}), struct{}{})
+	assert.Equal(t, errScope, reply.(error))
+	assert.False(t, called)
+}
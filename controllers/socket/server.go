@@ -12,12 +12,12 @@ func RegisterHandlers() {
 	socket.AuthServer.OnDisconnect = hooks.OnDisconnect
 	socket.UnauthServer.OnDisconnect = func(string, *jwt.Token) { pprof.Clients.Add(-1) }
 
-	socket.AuthServer.Register(handler.Global{}) //Global Handlers
-	socket.AuthServer.Register(handler.Lobby{})  //Lobby Handlers
-	socket.AuthServer.Register(handler.Player{}) //Player Handlers
-	socket.AuthServer.Register(handler.Chat{})   //Chat Handlers
-	socket.AuthServer.Register(handler.Serveme{})
-	socket.AuthServer.Register(handler.Mumble{})
+	registerScoped(socket.AuthServer, handler.Global{}) //Global Handlers
+	registerScoped(socket.AuthServer, handler.Lobby{})  //Lobby Handlers
+	registerScoped(socket.AuthServer, handler.Player{}) //Player Handlers
+	registerScoped(socket.AuthServer, handler.Chat{})   //Chat Handlers
+	registerScoped(socket.AuthServer, handler.Serveme{})
+	registerScoped(socket.AuthServer, handler.Mumble{})
 
 	socket.UnauthServer.Register(handler.Unauth{})
 }
@@ -0,0 +1,205 @@
+package sessions
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TF2Stadium/Helen/config"
+)
+
+//time without violations after which a player's strikes are forgotten
+const strikeDecay = 10 * time.Minute
+
+//number of recent messages checked for duplicates
+const recentMessages = 3
+
+var ErrDuplicateMessage = errors.New("You've already sent that message, don't repeat yourself")
+
+//bucket is a token bucket, refilled with a token every rate
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+//refill adds the tokens accumulated since the bucket was last refilled, and
+//returns whether there's a token to take
+func (b *bucket) refill(burst int, rate time.Duration, now time.Time) bool {
+	if b.last.IsZero() {
+		b.tokens = float64(burst)
+	} else {
+		b.tokens += float64(now.Sub(b.last)) / float64(rate)
+		if b.tokens > float64(burst) {
+			b.tokens = float64(burst)
+		}
+	}
+	b.last = now
+	return b.tokens >= 1
+}
+
+type sentMessage struct {
+	text string
+	at   time.Time
+}
+
+//chatState is the chat rate limiting state of a single player, shared by all
+//their sockets so opening more tabs doesn't raise the limit
+type chatState struct {
+	global   bucket
+	rooms    map[int]*bucket
+	recent   []sentMessage
+	strikes  uint
+	struck   time.Time // time of the last violation
+	cooldown time.Time // no messages are accepted till then
+	seen     time.Time
+}
+
+type chatLimits struct {
+	burst, roomBurst int
+	rate, roomRate   time.Duration
+	duplicate        time.Duration
+	cooldown, max    time.Duration
+}
+
+func currentLimits() chatLimits {
+	return chatLimits{
+		burst:     config.Constants.ChatBurst,
+		rate:      config.Constants.ChatRate,
+		roomBurst: config.Constants.ChatRoomBurst,
+		roomRate:  config.Constants.ChatRoomRate,
+		duplicate: config.Constants.ChatDuplicateWindow,
+		cooldown:  config.Constants.ChatCooldown,
+		max:       config.Constants.ChatCooldownMax,
+	}
+}
+
+type chatLimiter struct {
+	mu     *sync.Mutex
+	states map[string]*chatState
+	pruned time.Time
+}
+
+var chatLimit = newChatLimiter()
+
+func newChatLimiter() *chatLimiter {
+	return &chatLimiter{
+		mu:     new(sync.Mutex),
+		states: make(map[string]*chatState),
+	}
+}
+
+//normalize makes messages differing only in case or whitespace compare equal
+func normalize(message string) string {
+	return strings.ToLower(strings.Join(strings.Fields(message), " "))
+}
+
+//strike records a violation, and puts the player on a cooldown that
+//doubles with every violation they make before their strikes decay
+func (s *chatState) strike(limits chatLimits, now time.Time) {
+	if now.Sub(s.struck) > strikeDecay {
+		s.strikes = 0
+	}
+	s.strikes++
+	s.struck = now
+
+	// the first violation only drops the message
+	if s.strikes == 1 || limits.cooldown == 0 {
+		return
+	}
+
+	cooldown := limits.cooldown
+	for i := uint(2); i < s.strikes && cooldown < limits.max; i++ {
+		cooldown *= 2
+	}
+	if limits.max != 0 && cooldown > limits.max {
+		cooldown = limits.max
+	}
+	s.cooldown = now.Add(cooldown)
+}
+
+func (l *chatLimiter) allow(steamid string, room int, message string, limits chatLimits, now time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.prune(limits, now)
+
+	s, ok := l.states[steamid]
+	if !ok {
+		s = &chatState{rooms: make(map[int]*bucket)}
+		l.states[steamid] = s
+	}
+	s.seen = now
+
+	if now.Before(s.cooldown) {
+		return fmt.Errorf("You're sending messages too fast, wait %d seconds", int(s.cooldown.Sub(now).Seconds())+1)
+	}
+
+	text := normalize(message)
+	if limits.duplicate != 0 {
+		for _, sent := range s.recent {
+			if sent.text == text && now.Sub(sent.at) < limits.duplicate {
+				s.strike(limits, now)
+				return ErrDuplicateMessage
+			}
+		}
+	}
+
+	b, ok := s.rooms[room]
+	if !ok {
+		b = new(bucket)
+		s.rooms[room] = b
+	}
+
+	// tokens are only taken when the message is within both limits
+	if limits.burst != 0 && !s.global.refill(limits.burst, limits.rate, now) {
+		s.strike(limits, now)
+		return errors.New("You're sending messages too fast")
+	}
+	if limits.roomBurst != 0 && !b.refill(limits.roomBurst, limits.roomRate, now) {
+		s.strike(limits, now)
+		return errors.New("You're sending messages too fast in this room")
+	}
+	s.global.tokens--
+	b.tokens--
+
+	s.recent = append(s.recent, sentMessage{text, now})
+	if len(s.recent) > recentMessages {
+		s.recent = s.recent[1:]
+	}
+	return nil
+}
+
+//prune forgets players who haven't chatted for long enough that their state
+//is back to its initial one, at most once a minute
+func (l *chatLimiter) prune(limits chatLimits, now time.Time) {
+	if now.Sub(l.pruned) < time.Minute {
+		return
+	}
+	l.pruned = now
+
+	idle := strikeDecay
+	if d := time.Duration(limits.burst) * limits.rate; d > idle {
+		idle = d
+	}
+	if d := time.Duration(limits.roomBurst) * limits.roomRate; d > idle {
+		idle = d
+	}
+	if limits.max > idle {
+		idle = limits.max
+	}
+
+	for steamid, s := range l.states {
+		if now.Sub(s.seen) > idle {
+			delete(l.states, steamid)
+		}
+	}
+}
+
+//AllowChat records a chat message sent by steamid to room, returning an
+//error if the player is over the rate limit, on a cooldown, or repeating
+//themselves. The limits apply to the player across all of their sockets.
+func AllowChat(steamid string, room int, message string) error {
+	return chatLimit.allow(steamid, room, message, currentLimits(), time.Now())
+}
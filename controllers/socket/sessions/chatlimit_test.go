@@ -0,0 +1,68 @@
+package sessions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testLimits = chatLimits{
+	burst:     4,
+	rate:      time.Second,
+	roomBurst: 3,
+	roomRate:  2 * time.Second,
+	duplicate: 30 * time.Second,
+	cooldown:  10 * time.Second,
+	max:       time.Minute,
+}
+
+func TestChatRateLimit(t *testing.T) {
+	t.Parallel()
+	l := newChatLimiter()
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, l.allow("1", 0, string(rune('a'+i)), testLimits, now))
+	}
+	// room bucket is empty, but other rooms have their own
+	assert.Error(t, l.allow("1", 0, "d", testLimits, now))
+	assert.NoError(t, l.allow("1", 1, "e", testLimits, now))
+	// global bucket is empty too
+	assert.Error(t, l.allow("1", 2, "f", testLimits, now))
+	// other players aren't affected
+	assert.NoError(t, l.allow("2", 0, "a", testLimits, now))
+
+	// which was the second violation, so the player is on a cooldown
+	assert.Error(t, l.allow("1", 3, "g", testLimits, now.Add(9*time.Second)))
+	assert.NoError(t, l.allow("1", 3, "g", testLimits, now.Add(11*time.Second)))
+}
+
+func TestChatDuplicates(t *testing.T) {
+	t.Parallel()
+	l := newChatLimiter()
+	now := time.Now()
+
+	assert.NoError(t, l.allow("1", 0, "buy gold", testLimits, now))
+	assert.Equal(t, ErrDuplicateMessage, l.allow("1", 0, "BUY  gold ", testLimits, now.Add(time.Second)))
+	assert.NoError(t, l.allow("1", 0, "buy gold", testLimits, now.Add(31*time.Second)))
+}
+
+func TestChatCooldownEscalation(t *testing.T) {
+	t.Parallel()
+	s := &chatState{}
+	now := time.Now()
+
+	s.strike(testLimits, now)
+	assert.True(t, s.cooldown.IsZero())
+
+	for _, cooldown := range []time.Duration{10, 20, 40, 60, 60} {
+		s.strike(testLimits, now)
+		assert.Equal(t, now.Add(cooldown*time.Second), s.cooldown)
+	}
+
+	// strikes are forgotten after a while
+	now = now.Add(strikeDecay + time.Second)
+	s.strike(testLimits, now)
+	assert.Equal(t, uint(1), s.strikes)
+}
@@ -0,0 +1,98 @@
+package sessions
+
+import (
+	"sync"
+
+	"github.com/TF2Stadium/wsevent"
+)
+
+type roomMember struct {
+	so      *wsevent.Client
+	steamid string
+}
+
+var (
+	roomsMu     = new(sync.RWMutex)
+	roomMembers = make(map[string]map[string]roomMember) //room -> socket id -> member, only logged in sockets
+	socketRooms = make(map[string][]string)              //socket id -> rooms it's a tracked member of
+)
+
+//JoinRoom adds so to room r on server. Logged in sockets (steamid isn't empty)
+//are tracked, so messages can be sent to only some of a room's members.
+func JoinRoom(server *wsevent.Server, so *wsevent.Client, steamid, r string) {
+	server.Join(so, r)
+	if steamid == "" {
+		return
+	}
+
+	roomsMu.Lock()
+	defer roomsMu.Unlock()
+
+	members, ok := roomMembers[r]
+	if !ok {
+		members = make(map[string]roomMember)
+		roomMembers[r] = members
+	}
+	if _, ok := members[so.ID]; !ok {
+		socketRooms[so.ID] = append(socketRooms[so.ID], r)
+	}
+	members[so.ID] = roomMember{so, steamid}
+}
+
+//LeaveRoom removes so from room r on server
+func LeaveRoom(server *wsevent.Server, so *wsevent.Client, r string) {
+	server.Leave(so, r)
+
+	roomsMu.Lock()
+	defer roomsMu.Unlock()
+	removeMember(so.ID, r)
+}
+
+func removeMember(socketID, r string) {
+	delete(roomMembers[r], socketID)
+	if len(roomMembers[r]) == 0 {
+		delete(roomMembers, r)
+	}
+
+	rooms := socketRooms[socketID]
+	for i, room := range rooms {
+		if room == r {
+			rooms = append(rooms[:i], rooms[i+1:]...)
+			break
+		}
+	}
+	if len(rooms) == 0 {
+		delete(socketRooms, socketID)
+	} else {
+		socketRooms[socketID] = rooms
+	}
+}
+
+//RemoveRooms forgets the rooms the socket with the given ID was in, called
+//when it disconnects. wsevent removes it from the rooms themselves.
+func RemoveRooms(socketID string) {
+	roomsMu.Lock()
+	defer roomsMu.Unlock()
+
+	for _, r := range socketRooms[socketID] {
+		delete(roomMembers[r], socketID)
+		if len(roomMembers[r]) == 0 {
+			delete(roomMembers, r)
+		}
+	}
+	delete(socketRooms, socketID)
+}
+
+//RoomMembers calls f for every logged in socket in room r
+func RoomMembers(r string, f func(so *wsevent.Client, steamid string)) {
+	roomsMu.RLock()
+	members := make([]roomMember, 0, len(roomMembers[r]))
+	for _, member := range roomMembers[r] {
+		members = append(members, member)
+	}
+	roomsMu.RUnlock()
+
+	for _, member := range members {
+		f(member.so, member.steamid)
+	}
+}
@@ -0,0 +1,39 @@
+package sessions
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/TF2Stadium/wsevent"
+	"github.com/stretchr/testify/assert"
+)
+
+func members(r string) []string {
+	var steamids []string
+	RoomMembers(r, func(_ *wsevent.Client, steamid string) {
+		steamids = append(steamids, steamid)
+	})
+	sort.Strings(steamids)
+	return steamids
+}
+
+func TestRoomMembers(t *testing.T) {
+	server := wsevent.NewServer(nil, func(_ *wsevent.Client, _ struct{}) interface{} { return nil })
+	a := &wsevent.Client{ID: "a"}
+	b := &wsevent.Client{ID: "b"}
+	anon := &wsevent.Client{ID: "anon"}
+
+	JoinRoom(server, a, "1", "room")
+	JoinRoom(server, b, "2", "room")
+	JoinRoom(server, anon, "", "room")
+	JoinRoom(server, a, "1", "other")
+	assert.Equal(t, []string{"1", "2"}, members("room"))
+
+	LeaveRoom(server, b, "room")
+	assert.Equal(t, []string{"1"}, members("room"))
+
+	RemoveRooms("a")
+	assert.Empty(t, members("room"))
+	assert.Empty(t, members("other"))
+	assert.Empty(t, socketRooms)
+}
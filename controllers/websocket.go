@@ -19,7 +19,11 @@ import (
 var upgrader = websocket.Upgrader{CheckOrigin: func(_ *http.Request) bool { return true }}
 
 func SocketHandler(w http.ResponseWriter, r *http.Request) {
-	token, err := chelpers.GetToken(r)
+	token, err := chelpers.GetAPIToken(r)
+	if err != nil && r.Header.Get("Authorization") != "" { //invalid API token
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
 	if err != nil && err != http.ErrNoCookie { //invalid jwt token
 		logrus.Errorf("Error reading JWT: %v", err)
 		token = nil
@@ -74,12 +78,13 @@ func SocketInit(so *wsevent.Client) error {
 
 		steamid := so.Token.Claims.(*chelpers.TF2StadiumClaims).SteamID
 
-		player, err := player.GetPlayerBySteamID(steamid)
+		p, err := player.GetPlayerBySteamID(steamid)
 		if err != nil {
 			return fmt.Errorf("Couldn't find player record for %s", steamid)
 		}
+		p.RecordIP(chelpers.GetIPAddr(so.Request), player.IPSocket)
 
-		hooks.AfterConnectLoggedIn(so, player)
+		hooks.AfterConnectLoggedIn(so, p)
 	} else {
 		hooks.AfterConnect(socket.UnauthServer, so)
 		so.EmitJSON(helpers.NewRequest("playerSettings", "{}"))
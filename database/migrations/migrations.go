@@ -9,10 +9,16 @@ import (
 
 	"github.com/TF2Stadium/Helen/database"
 	"github.com/TF2Stadium/Helen/models"
+	"github.com/TF2Stadium/Helen/models/apitoken"
 	"github.com/TF2Stadium/Helen/models/chat"
+	"github.com/TF2Stadium/Helen/models/demo"
 	"github.com/TF2Stadium/Helen/models/gameserver"
 	"github.com/TF2Stadium/Helen/models/lobby"
+	"github.com/TF2Stadium/Helen/models/lobby/timeline"
+	"github.com/TF2Stadium/Helen/models/notification"
 	"github.com/TF2Stadium/Helen/models/player"
+	"github.com/TF2Stadium/Helen/models/region"
+	"github.com/TF2Stadium/Helen/models/role"
 )
 
 var once = new(sync.Once)
@@ -27,10 +33,32 @@ func Do() {
 	database.DB.AutoMigrate(&models.AdminLogEntry{})
 	database.DB.AutoMigrate(&player.PlayerBan{})
 	database.DB.AutoMigrate(&chat.ChatMessage{})
+	database.DB.AutoMigrate(&chat.Mute{})
+	database.DB.AutoMigrate(&chat.SlowMode{})
+	database.DB.AutoMigrate(&chat.Filter{})
+	database.DB.AutoMigrate(&chat.DirectMessage{})
+	database.DB.AutoMigrate(&notification.Notification{})
 	database.DB.AutoMigrate(&lobby.Requirement{})
 	database.DB.AutoMigrate(&Constant{})
 	database.DB.AutoMigrate(&gameserver.StoredServer{})
 	database.DB.AutoMigrate(&player.Report{})
+	database.DB.AutoMigrate(&player.BanAppeal{})
+	database.DB.AutoMigrate(&player.IPRecord{})
+	database.DB.AutoMigrate(&player.AltFlag{})
+	database.DB.AutoMigrate(&player.Block{})
+	database.DB.AutoMigrate(&demo.Demo{})
+	database.DB.AutoMigrate(&demo.DemoPlayer{})
+	database.DB.AutoMigrate(&region.Region{})
+	database.DB.AutoMigrate(&region.Override{})
+	database.DB.AutoMigrate(&role.Role{})
+	database.DB.AutoMigrate(&role.Grant{})
+	database.DB.AutoMigrate(&timeline.LobbyEvent{})
+	database.DB.AutoMigrate(&lobby.MatchResult{})
+	database.DB.AutoMigrate(&lobby.PlayerResult{})
+	database.DB.AutoMigrate(&lobby.ServemeSettings{})
+	database.DB.AutoMigrate(&apitoken.Token{})
+	database.DB.AutoMigrate(&apitoken.Usage{})
+	database.DB.AutoMigrate(&player.Session{})
 
 	database.DB.Model(&lobby.LobbySlot{}).
 		AddUniqueIndex("idx_lobby_slot_lobby_id_slot", "lobby_id", "slot")
@@ -39,5 +67,9 @@ func Do() {
 	database.DB.Model(&lobby.LobbySlot{}).
 		AddUniqueIndex("idx_requirement_lobby_id_slot", "lobby_id", "slot")
 
+	// for paging through chat history, and searching it
+	database.DB.Model(&chat.ChatMessage{}).AddIndex("idx_chat_messages_room_id", "room", "id")
+	database.DB.Exec("CREATE INDEX IF NOT EXISTS idx_chat_messages_search ON chat_messages USING gin(to_tsvector('simple', message))")
+
 	once.Do(checkSchema)
 }
@@ -16,6 +16,7 @@ import (
 	"github.com/TF2Stadium/Helen/models/lobby"
 	"github.com/TF2Stadium/Helen/models/lobby/format"
 	"github.com/TF2Stadium/Helen/models/player"
+	"github.com/TF2Stadium/Helen/models/role"
 	"github.com/jinzhu/gorm/dialects/postgres"
 )
 
@@ -34,6 +35,7 @@ var migrationRoutines = map[uint64]func(){
 	12: moveReportsServers,
 	13: dropUnusedColumns,
 	14: downloadSTVDemos,
+	15: grantReadDirectMessages,
 }
 
 func whitelist_id_string() {
@@ -220,3 +222,19 @@ func downloadSTVDemos() {
 		}(lob)
 	}
 }
+
+//built-in roles are only created once, so admin roles created before
+//ActionReadDirectMessages existed don't have it
+func grantReadDirectMessages() {
+	admin := &role.Role{}
+	if err := db.DB.Where("number = ?", helpers.RoleAdmin).First(admin).Error; err != nil {
+		return
+	}
+
+	action := helpers.ActionNames[helpers.ActionReadDirectMessages]
+	var count int
+	db.DB.Model(&role.Grant{}).Where("role_id = ? AND action = ?", admin.ID, action).Count(&count)
+	if count == 0 {
+		db.DB.Create(&role.Grant{RoleID: admin.ID, Action: action})
+	}
+}
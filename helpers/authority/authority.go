@@ -4,13 +4,21 @@
 
 package authority
 
-import "encoding/gob"
+import (
+	"encoding/gob"
+	"strconv"
+	"sync"
+)
 
 type AuthAction int
 
 type AuthRole int
 
-var permissions = make(map[AuthRole]map[AuthAction]bool)
+var (
+	mu          = new(sync.RWMutex)
+	permissions = make(map[AuthRole]map[AuthAction]bool)
+	names       = make(map[AuthRole]string)
+)
 
 func init() {
 	gob.Register(AuthAction(0))
@@ -18,6 +26,9 @@ func init() {
 }
 
 func (role AuthRole) Allow(action AuthAction) AuthRole {
+	mu.Lock()
+	defer mu.Unlock()
+
 	amap, ok := permissions[role]
 	if !ok {
 		amap = make(map[AuthAction]bool)
@@ -29,6 +40,9 @@ func (role AuthRole) Allow(action AuthAction) AuthRole {
 }
 
 func (role AuthRole) Disallow(action AuthAction) AuthRole {
+	mu.Lock()
+	defer mu.Unlock()
+
 	amap, ok := permissions[role]
 	if !ok {
 		amap = make(map[AuthAction]bool)
@@ -40,6 +54,9 @@ func (role AuthRole) Disallow(action AuthAction) AuthRole {
 }
 
 func (myrole AuthRole) Inherit(otherrole AuthRole) AuthRole {
+	mu.Lock()
+	defer mu.Unlock()
+
 	mymap, ok := permissions[myrole]
 	if !ok {
 		mymap = make(map[AuthAction]bool)
@@ -59,6 +76,9 @@ func (myrole AuthRole) Inherit(otherrole AuthRole) AuthRole {
 }
 
 func (role AuthRole) Can(action AuthAction) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+
 	mymap, ok := permissions[role]
 	return ok && mymap[action]
 }
@@ -68,6 +88,36 @@ func Can(role_int int, action AuthAction) bool {
 	return role.Can(action)
 }
 
+//SetName sets the name of the role
+func (role AuthRole) SetName(name string) AuthRole {
+	mu.Lock()
+	names[role] = name
+	mu.Unlock()
+	return role
+}
+
+//Name returns the role's name, or its number if it doesn't have one
+func (role AuthRole) Name() string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if name, ok := names[role]; ok {
+		return name
+	}
+	return strconv.Itoa(int(role))
+}
+
+//Load replaces all permissions and role names
+func Load(perms map[AuthRole]map[AuthAction]bool, roleNames map[AuthRole]string) {
+	mu.Lock()
+	permissions = perms
+	names = roleNames
+	mu.Unlock()
+}
+
 func Reset() {
+	mu.Lock()
 	permissions = make(map[AuthRole]map[AuthAction]bool)
+	names = make(map[AuthRole]string)
+	mu.Unlock()
 }
@@ -52,3 +52,18 @@ func TestInherit(t *testing.T) {
 	RoleAdmin.Disallow(ActionTwo)
 	assert.False(t, RoleAdmin.Can(ActionTwo))
 }
+
+func TestLoad(t *testing.T) {
+	Reset()
+	RoleNormal.Allow(ActionOne).SetName("normal")
+	assert.Equal(t, "normal", RoleNormal.Name())
+
+	Load(map[AuthRole]map[AuthAction]bool{
+		RoleAdmin: {ActionTwo: true},
+	}, map[AuthRole]string{RoleAdmin: "admin"})
+
+	assert.False(t, RoleNormal.Can(ActionOne))
+	assert.True(t, RoleAdmin.Can(ActionTwo))
+	assert.Equal(t, "admin", RoleAdmin.Name())
+	assert.Equal(t, "0", RoleNormal.Name())
+}
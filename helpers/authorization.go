@@ -7,25 +7,31 @@ package helpers
 import "github.com/TF2Stadium/Helen/helpers/authority"
 
 // DO NOT CHANGE THE INTEGER VALUES OF ALREADY EXISTING ROLES.
+//Roles created in /admin/roles get numbers after these.
 const (
 	RolePlayer authority.AuthRole = iota
 	RoleMod
 	RoleAdmin
 	RoleDeveloper
+	RoleLobbyMod
 )
 
+//RoleNames and RoleMap only have the built-in roles,
+//use AuthRole.Name for roles stored in the database.
 var RoleNames = map[authority.AuthRole]string{
 	RoleDeveloper: "developer",
 	RolePlayer:    "player",
 	RoleMod:       "moderator",
 	RoleAdmin:     "administrator",
+	RoleLobbyMod:  "lobby moderator",
 }
 
 var RoleMap = map[string]authority.AuthRole{
-	"player":        RolePlayer,
-	"moderator":     RoleMod,
-	"administrator": RoleAdmin,
-	"developer":     RoleDeveloper,
+	"player":          RolePlayer,
+	"moderator":       RoleMod,
+	"administrator":   RoleAdmin,
+	"developer":       RoleDeveloper,
+	"lobby moderator": RoleLobbyMod,
 }
 
 // You can't change the order of these
@@ -38,28 +44,68 @@ const (
 	ActionViewPage //view admin pages
 	ActionDeleteChat
 	ModifyServers //add/remove servers
+	ActionDeleteDemos
+	ActionManageRegions
+	ActionCloseLobby         //close lobbies created by others
+	ActionManageLobbies      //reset servers, remove restrictions and shuffle lobbies created by others, create multiple lobbies
+	ActionKickFromLobby      //kick players from lobbies created by others
+	ActionReadDirectMessages //read direct messages between players
 )
 
+//ActionNames are used to store grants in the database, so they can't be changed either
 var ActionNames = map[authority.AuthAction]string{
 	ActionBanCreate: "ActionBanCreate",
 	ActionBanJoin:   "ActionBanJoin",
 	ActionBanChat:   "ActionBanChat",
 
-	ActionChangeRole: "ActionChangeRole",
+	ActionChangeRole:    "ActionChangeRole",
+	ActionViewLogs:      "ActionViewLogs",
+	ActionViewPage:      "ActionViewPage",
+	ActionDeleteChat:    "ActionDeleteChat",
+	ModifyServers:       "ModifyServers",
+	ActionDeleteDemos:   "ActionDeleteDemos",
+	ActionManageRegions: "ActionManageRegions",
+	ActionCloseLobby:    "ActionCloseLobby",
+	ActionManageLobbies: "ActionManageLobbies",
+	ActionKickFromLobby: "ActionKickFromLobby",
+
+	ActionReadDirectMessages: "ActionReadDirectMessages",
 }
 
-func init() {
-	RoleDeveloper.Allow(ActionViewPage)
+//DefaultRole is a built-in role, created in the database if it doesn't exist
+type DefaultRole struct {
+	Role    authority.AuthRole
+	Parent  authority.AuthRole // -1 if the role doesn't inherit another one
+	Actions []authority.AuthAction
+}
 
-	RoleMod.Inherit(RolePlayer)
-	RoleMod.Allow(ActionBanChat)
-	RoleMod.Allow(ActionBanJoin)
-	RoleMod.Allow(ActionBanCreate)
-	RoleMod.Allow(ActionViewLogs)
-	RoleMod.Allow(ActionViewPage)
-	RoleMod.Allow(ActionDeleteChat)
-	RoleMod.Allow(ModifyServers)
+//DefaultRoles are the built-in roles, parents come before their children
+var DefaultRoles = []DefaultRole{
+	{RolePlayer, -1, nil},
+	{RoleDeveloper, -1, []authority.AuthAction{ActionViewPage}},
+	{RoleLobbyMod, RolePlayer, []authority.AuthAction{
+		ActionCloseLobby, ActionManageLobbies,
+	}},
+	{RoleMod, RolePlayer, []authority.AuthAction{
+		ActionBanChat, ActionBanJoin, ActionBanCreate, ActionViewLogs,
+		ActionViewPage, ActionDeleteChat, ModifyServers,
+		ActionCloseLobby, ActionManageLobbies,
+	}},
+	{RoleAdmin, RoleMod, []authority.AuthAction{
+		ActionChangeRole, ActionDeleteDemos, ActionManageRegions, ActionKickFromLobby,
+		ActionReadDirectMessages,
+	}},
+}
 
-	RoleAdmin.Inherit(RoleMod)
-	RoleAdmin.Allow(ActionChangeRole)
+//used until roles are loaded from the database
+func init() {
+	for _, def := range DefaultRoles {
+		def.Role.SetName(RoleNames[def.Role])
+		if def.Parent != -1 {
+			def.Role.Inherit(def.Parent)
+		}
+		for _, action := range def.Actions {
+			def.Role.Allow(action)
+		}
+	}
 }
@@ -1,8 +1,12 @@
 package helpers
 
 import (
+	"io/ioutil"
 	"net"
+	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/TF2Stadium/Helen/assets"
 	"github.com/TF2Stadium/Helen/config"
@@ -10,19 +14,123 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-var geodb *geoip2.Reader
+var (
+	geoMu   = new(sync.RWMutex)
+	geodb   *geoip2.Reader
+	geoFile string    // path the database was loaded from, empty if embedded
+	geoMod  time.Time // modification time of geoFile when it was loaded
+
+	// lookup results, cleared when the database is reloaded
+	geoCache = make(map[string][2]string)
+)
+
+//maximum number of cached lookups, the cache is cleared when it's full
+const geoCacheSize = 10000
 
 func InitGeoIPDB() {
 	if !config.Constants.GeoIP {
 		return
 	}
 
-	var err error
-	geodb, err = geoip2.FromBytes(assets.GeoIPDB)
+	if config.Constants.GeoIPPath == "" {
+		reader, err := geoip2.FromBytes(assets.GeoIPDB)
+		if err != nil {
+			logrus.Fatal(err.Error())
+		}
+		swapGeoIPDB(reader, "", time.Time{})
+		return
+	}
 
-	if err != nil {
+	if err := loadGeoIPFile(config.Constants.GeoIPPath); err != nil {
 		logrus.Fatal(err.Error())
 	}
+	go watchGeoIPFile(config.Constants.GeoIPPath)
+}
+
+//loadGeoIPFile reads the database at path, and swaps it with the current one.
+//The file is read into memory, so it can be replaced while Helen is running.
+func loadGeoIPFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	reader, err := geoip2.FromBytes(bytes)
+	if err != nil {
+		return err
+	}
+
+	swapGeoIPDB(reader, path, info.ModTime())
+	logrus.Infof("Loaded GeoIP database from %s (built %s)", path, GeoIPBuildDate().Format(time.RFC822))
+	return nil
+}
+
+func swapGeoIPDB(reader *geoip2.Reader, path string, modTime time.Time) {
+	geoMu.Lock()
+	old := geodb
+	geodb = reader
+	geoFile = path
+	geoMod = modTime
+	geoCache = make(map[string][2]string)
+	geoMu.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+}
+
+//watchGeoIPFile reloads the database when the file at path is modified
+func watchGeoIPFile(path string) {
+	for {
+		time.Sleep(config.Constants.GeoIPReload)
+
+		info, err := os.Stat(path)
+		if err != nil {
+			logrus.Error(err)
+			continue
+		}
+
+		geoMu.RLock()
+		changed := !info.ModTime().Equal(geoMod)
+		geoMu.RUnlock()
+
+		if changed {
+			if err := loadGeoIPFile(path); err != nil {
+				// keep using the old database
+				logrus.Error("Couldn't reload GeoIP database: ", err)
+			}
+		}
+	}
+}
+
+//GeoIPBuildDate returns when the loaded GeoIP database was built
+func GeoIPBuildDate() time.Time {
+	geoMu.RLock()
+	defer geoMu.RUnlock()
+
+	if geodb == nil {
+		return time.Time{}
+	}
+	return time.Unix(int64(geodb.Metadata().BuildEpoch), 0)
+}
+
+//GeoIPSource returns the path of the loaded GeoIP database
+func GeoIPSource() string {
+	geoMu.RLock()
+	defer geoMu.RUnlock()
+
+	if geodb == nil {
+		return "disabled"
+	}
+	if geoFile == "" {
+		return "embedded"
+	}
+	return geoFile
 }
 
 func GetRegion(server string) (string, string) {
@@ -36,15 +144,34 @@ func GetRegion(server string) (string, string) {
 		logrus.Error(err.Error())
 		return "", ""
 	}
+	key := addr.IP.String()
 
+	geoMu.RLock()
+	cached, ok := geoCache[key]
+	geoMu.RUnlock()
+	if ok {
+		return cached[0], cached[1]
+	}
+
+	geoMu.RLock()
 	record, err := geodb.Country(addr.IP)
+	geoMu.RUnlock()
 	if err != nil {
 		logrus.Error(err.Error())
 		return "", ""
 	}
 
+	result := [2]string{strings.ToLower(record.Continent.Code), record.Continent.Names["en"]}
 	if record.Country.Names["en"] == "Russia" {
-		return "ru", "Russia"
+		result = [2]string{"ru", "Russia"}
 	}
-	return strings.ToLower(record.Continent.Code), record.Continent.Names["en"]
+
+	geoMu.Lock()
+	if len(geoCache) >= geoCacheSize {
+		geoCache = make(map[string][2]string)
+	}
+	geoCache[key] = result
+	geoMu.Unlock()
+
+	return result[0], result[1]
 }
@@ -1,8 +1,14 @@
 package helpers
 
 import (
+	encjson "encoding/json"
+	"fmt"
 	"net"
+	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/TF2Stadium/Helen/config"
 	"github.com/TF2Stadium/servemetf"
@@ -45,6 +51,52 @@ func GetServemeContext(addrStr string) *servemetf.Context {
 	return GetServemeContextIP(addr.String())
 }
 
+var servemeClient = &http.Client{Timeout: 10 * time.Second}
+
+func servemeRequest(context *servemetf.Context, method, path, steamID string) (servemetf.Reservation, error) {
+	u := url.URL{
+		Scheme: "https",
+		Host:   context.Host,
+		Path:   path,
+	}
+
+	values := u.Query()
+	values.Set("api_key", context.APIKey)
+	values.Set("steam_uid", steamID)
+	u.RawQuery = values.Encode()
+
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return servemetf.Reservation{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := servemeClient.Do(req)
+	if err != nil {
+		return servemetf.Reservation{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return servemetf.Reservation{}, fmt.Errorf("serveme: %s %s returned %s", method, path, resp.Status)
+	}
+
+	var jsonresp servemetf.Response
+	err = encjson.NewDecoder(resp.Body).Decode(&jsonresp)
+	return jsonresp.Reservation, err
+}
+
+//GetServemeReservation returns the serveme reservation with the given id
+func GetServemeReservation(context *servemetf.Context, id int, steamID string) (servemetf.Reservation, error) {
+	return servemeRequest(context, "GET", "api/reservations/"+strconv.Itoa(id), steamID)
+}
+
+//ExtendServemeReservation extends the given serveme reservation, returning the
+//updated reservation. serveme.tf decides how long it's extended by.
+func ExtendServemeReservation(context *servemetf.Context, id int, steamID string) (servemetf.Reservation, error) {
+	return servemeRequest(context, "POST", "api/reservations/"+strconv.Itoa(id)+"/extend", steamID)
+}
+
 func init() {
 	ServemeNA.APIKey = config.Constants.ServemeAPIKey
 	ServemeEU.APIKey = config.Constants.ServemeAPIKey
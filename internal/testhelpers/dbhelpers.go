@@ -43,16 +43,38 @@ func CleanupDB() {
 
 	tables := []string{
 		"admin_log_entries",
+		"alt_flags",
+		"api_token_usages",
+		"api_tokens",
+		"ban_appeals",
 		"banned_players_lobbies",
+		"chat_filters",
 		"chat_messages",
+		"chat_mutes",
+		"chat_slow_modes",
+		"demo_players",
+		"demos",
+		"direct_messages",
 		"lobbies",
+		"lobby_events",
 		"lobby_slots",
+		"match_results",
+		"notifications",
 		"player_bans",
+		"player_blocks",
+		"player_ips",
+		"player_results",
+		"player_sessions",
 		"player_stats",
 		"players",
+		"region_overrides",
+		"regions",
 		"reports",
 		"requirements",
+		"role_grants",
+		"roles",
 		"server_records",
+		"serveme_settings",
 		"spectators_players_lobbies",
 		"stored_servers",
 	}
@@ -0,0 +1,32 @@
+// Copyright (C) 2015  TF2Stadium
+// Use of this source code is governed by the GPLv3
+// that can be found in the COPYING file.
+
+package testhelpers
+
+import (
+	"github.com/TF2Stadium/Helen/models/event"
+	"github.com/TF2Stadium/Helen/models/rpc"
+)
+
+//StartInProcess replaces the RabbitMQ transports with in-process ones.
+//The returned Memory records all RPC calls made by Helen, events can be
+//sent to Helen with (*MemorySource).Inject.
+func StartInProcess() (*rpc.Memory, *event.MemorySource) {
+	memory := rpc.NewMemory()
+	rpc.UseMemory(memory)
+
+	source := event.NewMemorySource()
+	event.StartListening(source)
+
+	return memory, source
+}
+
+//InjectEvent sends an event with the given name for the lobby/player to source
+func InjectEvent(source *event.MemorySource, name string, lobbyID uint, steamID string) {
+	source.Inject(event.Event{
+		Name:    name,
+		LobbyID: lobbyID,
+		SteamID: steamID,
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"encoding/base64"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
@@ -27,9 +28,13 @@ import (
 	_ "github.com/TF2Stadium/Helen/internal/pprof"    // to setup expvars
 	"github.com/TF2Stadium/Helen/internal/version"
 	"github.com/TF2Stadium/Helen/models/chat"
+	"github.com/TF2Stadium/Helen/models/demo"
 	"github.com/TF2Stadium/Helen/models/event"
 	"github.com/TF2Stadium/Helen/models/lobby"
 	"github.com/TF2Stadium/Helen/models/lobby_settings"
+	"github.com/TF2Stadium/Helen/models/player"
+	"github.com/TF2Stadium/Helen/models/region"
+	"github.com/TF2Stadium/Helen/models/role"
 	"github.com/TF2Stadium/Helen/models/rpc"
 	"github.com/TF2Stadium/Helen/routes"
 	socketServer "github.com/TF2Stadium/Helen/routes/socket"
@@ -38,9 +43,11 @@ import (
 )
 
 var (
-	flagGen   = flag.Bool("genkey", false, "write a 32bit key for encrypting cookies the given file, and exit")
-	docPrint  = flag.Bool("printdoc", false, "print the docs for environment variables, and exit.")
-	dbMaxopen = flag.Int("db-maxopen", 80, "maximum number of open database connections allowed.")
+	flagGen      = flag.Bool("genkey", false, "write a 32bit key for encrypting cookies the given file, and exit")
+	docPrint     = flag.Bool("printdoc", false, "print the docs for environment variables, and exit.")
+	dbMaxopen    = flag.Int("db-maxopen", 80, "maximum number of open database connections allowed.")
+	inProcess    = flag.Bool("inprocess", false, "use in-process RPC and events instead of RabbitMQ, for local development")
+	migrateDemos = flag.Bool("migrate-demos", false, "move demos in DEMOS_FOLDER to the configured demo storage, and exit")
 )
 
 func main() {
@@ -98,18 +105,49 @@ func main() {
 	database.DB.DB().SetMaxOpenConns(*dbMaxopen)
 	migrations.Do()
 
-	helpers.ConnectAMQP()
-	event.StartListening()
+	demo.InitStorage()
+	if *migrateDemos {
+		if err := demo.MigrateFolder(config.Constants.DemosFolder); err != nil {
+			logrus.Fatal(err)
+		}
+		return
+	}
+
+	if *inProcess {
+		logrus.Warning("Using in-process RPC and events, Pauling/Fumble/TwitchBot won't be called")
+		rpc.UseMemory(rpc.NewMemory())
+		event.StartListening(event.NewMemorySource())
+	} else {
+		helpers.ConnectAMQP()
+		event.StartListening(event.NewAMQPSource(helpers.AMQPChannel, config.Constants.RabbitMQQueue))
+	}
 	helpers.InitGeoIPDB()
+	region.Reload()
+	role.Reload()
+	chat.Reload()
 
 	err = lobbySettings.LoadLobbySettingsFromFile("assets/lobbySettingsData.json")
 	if err != nil {
 		logrus.Fatal(err)
 	}
 
+	if config.Constants.AutoBanPolicy != "" {
+		data, err := ioutil.ReadFile(config.Constants.AutoBanPolicy)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if err := player.LoadAutoBanPolicy(data); err != nil {
+			logrus.Fatal(err)
+		}
+	}
+
 	lobby.CreateLocks()
-	rpc.ConnectRPC(helpers.AMQPConn)
+	if !*inProcess {
+		rpc.ConnectRPC(helpers.AMQPConn)
+	}
 	lobby.RestoreServemeChecks()
+	demo.StartRetention()
+	player.StartIPRetention()
 	//go models.TFTVStreamStatusUpdater()
 
 	if config.Constants.SteamIDWhitelist != "" {
@@ -124,7 +162,7 @@ func main() {
 		AllowedOrigins:   config.Constants.AllowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "DELETE", "OPTIONS"},
 		AllowCredentials: true,
-	}).Handler(mux)
+	}).Handler(chelpers.RefreshSession(mux))
 
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt, os.Kill, syscall.SIGTERM, syscall.SIGKILL)
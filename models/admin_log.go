@@ -5,17 +5,80 @@
 package models
 
 import (
+	"time"
+
 	"github.com/TF2Stadium/Helen/database"
 	"github.com/TF2Stadium/Helen/helpers"
 	"github.com/TF2Stadium/Helen/helpers/authority"
 	"github.com/jinzhu/gorm"
 )
 
+//Actions recorded in the audit log
+const (
+	AuditChangeRole       = "changeRole"
+	AuditAddRole          = "addRole"
+	AuditSetRoleParent    = "setRoleParent"
+	AuditGrantAction      = "grantAction"
+	AuditRevokeAction     = "revokeAction"
+	AuditRemoveRole       = "removeRole"
+	AuditBan              = "ban"
+	AuditUnban            = "unban"
+	AuditAddServer        = "addServer"
+	AuditRemoveServer     = "removeServer"
+	AuditDeleteDemo       = "deleteDemo"
+	AuditAddRegion        = "addRegion"
+	AuditAddOverride      = "addRegionOverride"
+	AuditRemoveOverride   = "removeRegionOverride"
+	AuditDeleteChat       = "deleteChat"
+	AuditCloseLobby       = "closeLobby"
+	AuditKick             = "kick"
+	AuditLobbyBan         = "lobbyBan"
+	AuditResetServer      = "resetServer"
+	AuditSetTeamName      = "setTeamName"
+	AuditRemoveRestrict   = "removeRestriction"
+	AuditShuffle          = "shuffle"
+	AuditCreateExtraLobby = "createExtraLobby"
+	AuditClaimReport      = "claimReport"
+	AuditResolveReport    = "resolveReport"
+	AuditAcceptAppeal     = "acceptAppeal"
+	AuditReduceAppeal     = "reduceAppeal"
+	AuditRejectAppeal     = "rejectAppeal"
+	AuditAutoBanExempt    = "autoBanExempt"
+	AuditConfirmAlt       = "confirmAlt"
+	AuditDismissAlt       = "dismissAlt"
+	AuditMute             = "mute"
+	AuditUnmute           = "unmute"
+	AuditSlowMode         = "slowMode"
+	AuditAddFilter        = "addChatFilter"
+	AuditRemoveFilter     = "removeChatFilter"
+	AuditReadDirect       = "readDirectMessages"
+	AuditMaxExtension     = "servemeMaxExtension"
+)
+
+//AuditActions lists every action, for filtering the log
+var AuditActions = []string{
+	AuditChangeRole, AuditAddRole, AuditSetRoleParent, AuditGrantAction,
+	AuditRevokeAction, AuditRemoveRole, AuditBan, AuditUnban, AuditAddServer,
+	AuditRemoveServer, AuditDeleteDemo, AuditAddRegion, AuditAddOverride,
+	AuditRemoveOverride, AuditDeleteChat, AuditCloseLobby, AuditKick,
+	AuditLobbyBan, AuditResetServer, AuditSetTeamName, AuditRemoveRestrict,
+	AuditShuffle, AuditCreateExtraLobby, AuditClaimReport, AuditResolveReport,
+	AuditAcceptAppeal, AuditReduceAppeal, AuditRejectAppeal, AuditAutoBanExempt,
+	AuditConfirmAlt, AuditDismissAlt, AuditMute, AuditUnmute, AuditSlowMode,
+	AuditAddFilter, AuditRemoveFilter, AuditReadDirect, AuditMaxExtension,
+}
+
 type AdminLogEntry struct {
 	gorm.Model
-	PlayerID uint   //Admin responsible for action
+	PlayerID uint   `sql:"index"`      //Admin responsible for action
 	RelID    uint   `sql:"default:0"`  //The targated player
 	RelText  string `sql:"default:''"` //The action text
+
+	Action string `sql:"index"` //One of AuditActions, empty for older entries
+	Target string `sql:"index"` //What the action was done to (SteamID, lobby ID, server address, etc)
+	Before string //Value before the change, if any
+	After  string //Value after the change, if any
+	IPAddr string //Address the admin did it from
 }
 
 func LogCustomAdminAction(playerid uint, reltext string, relid uint) error {
@@ -31,3 +94,56 @@ func LogCustomAdminAction(playerid uint, reltext string, relid uint) error {
 func LogAdminAction(playerid uint, permission authority.AuthAction, relid uint) error {
 	return LogCustomAdminAction(playerid, helpers.ActionNames[permission], relid)
 }
+
+//LogAudit adds entry to the audit log
+func LogAudit(entry *AdminLogEntry) error {
+	if entry.RelText == "" {
+		entry.RelText = entry.Action
+	}
+	return database.DB.Create(entry).Error
+}
+
+//AuditFilter selects entries from the audit log, zero fields are ignored
+type AuditFilter struct {
+	PlayerID uint
+	Action   string
+	Target   string
+	Since    time.Time
+	Until    time.Time
+}
+
+func (f AuditFilter) query() *gorm.DB {
+	query := database.DB.Model(&AdminLogEntry{})
+	if f.PlayerID != 0 {
+		query = query.Where("player_id = ?", f.PlayerID)
+	}
+	if f.Action != "" {
+		query = query.Where("action = ?", f.Action)
+	}
+	if f.Target != "" {
+		query = query.Where("target = ?", f.Target)
+	}
+	if !f.Since.IsZero() {
+		query = query.Where("created_at >= ?", f.Since)
+	}
+	if !f.Until.IsZero() {
+		query = query.Where("created_at < ?", f.Until)
+	}
+	return query
+}
+
+//GetAuditLog returns entries matching filter, newest first, along with the
+//total number of matching entries. A limit of 0 returns every entry.
+func GetAuditLog(filter AuditFilter, offset, limit int) ([]*AdminLogEntry, int) {
+	var total int
+	filter.query().Count(&total)
+
+	query := filter.query().Order("id desc").Offset(offset)
+	if limit != 0 {
+		query = query.Limit(limit)
+	}
+
+	var entries []*AdminLogEntry
+	query.Find(&entries)
+	return entries, total
+}
@@ -6,6 +6,7 @@ package models_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/TF2Stadium/Helen/database"
 	"github.com/TF2Stadium/Helen/helpers"
@@ -22,12 +23,35 @@ func TestLogCreation(t *testing.T) {
 	t.Parallel()
 	var obj = AdminLogEntry{}
 	count := 5
-	database.DB.Model(obj).Count(&count)
+	// only count this test's entries, TestAuditLog runs alongside it
+	database.DB.Model(obj).Where("player_id IN (1, 2)").Count(&count)
 	assert.Equal(t, 0, count)
 
 	LogAdminAction(1, helpers.ActionBanJoin, 2)
 	LogCustomAdminAction(2, "test", 4)
 
-	database.DB.Model(obj).Count(&count)
+	database.DB.Model(obj).Where("player_id IN (1, 2)").Count(&count)
 	assert.Equal(t, 2, count)
 }
+
+func TestAuditLog(t *testing.T) {
+	t.Parallel()
+	since := time.Now().Add(-time.Minute)
+
+	LogAudit(&AdminLogEntry{PlayerID: 10, Action: AuditBan, Target: "76561198011111111", After: "chat"})
+	LogAudit(&AdminLogEntry{PlayerID: 10, Action: AuditCloseLobby, Target: "5"})
+	LogAudit(&AdminLogEntry{PlayerID: 11, Action: AuditBan, Target: "76561198022222222", IPAddr: "127.0.0.1"})
+
+	entries, total := GetAuditLog(AuditFilter{PlayerID: 10, Since: since}, 0, 0)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, AuditCloseLobby, entries[0].Action)
+	assert.Equal(t, AuditBan, entries[1].RelText)
+
+	entries, total = GetAuditLog(AuditFilter{Action: AuditBan, Since: since}, 0, 1)
+	assert.Equal(t, 2, total)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "127.0.0.1", entries[0].IPAddr)
+
+	_, total = GetAuditLog(AuditFilter{Target: "76561198011111111", Until: since}, 0, 0)
+	assert.Zero(t, total)
+}
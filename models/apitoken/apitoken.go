@@ -0,0 +1,177 @@
+// Copyright (C) 2015  TF2Stadium
+// Use of this source code is governed by the GPLv3
+// that can be found in the COPYING file.
+
+//Package apitoken implements personal API tokens, which players can
+//create for third party clients (bots, overlays, etc)
+package apitoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	db "github.com/TF2Stadium/Helen/database"
+	"github.com/sirupsen/logrus"
+)
+
+//Scopes which can be given to tokens
+const (
+	ScopeReadProfile = "read-profile"
+	ScopeJoinLobby   = "join-lobby"
+	ScopeCreateLobby = "create-lobby"
+	ScopeChat        = "chat"
+)
+
+var validScopes = map[string]bool{
+	ScopeReadProfile: true,
+	ScopeJoinLobby:   true,
+	ScopeCreateLobby: true,
+	ScopeChat:        true,
+}
+
+//all tokens start with this, so they're easy to recognize (and grep for)
+const prefix = "tf2stadium_"
+
+//maximum number of tokens a player can have
+const maxTokens = 10
+
+var (
+	ErrInvalidToken = errors.New("Invalid API token")
+	ErrTooMany      = errors.New("You can't have more than 10 API tokens")
+)
+
+//Token is a personal API token. Only the token's hash is stored.
+type Token struct {
+	ID         uint      `json:"id"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+
+	PlayerID uint   `json:"-" sql:"index"`
+	Name     string `json:"name"`
+	Hint     string `json:"hint"` // first few characters of the token, to tell tokens apart
+	Hash     string `json:"-" sql:"not null;unique"`
+	Scopes   string `json:"-"` // comma separated list of scopes
+	Revoked  bool   `json:"-"`
+
+	ScopeList []string `json:"scopes" sql:"-"`
+}
+
+func (Token) TableName() string { return "api_tokens" }
+
+//Usage is a single use of a token, either over the socket or HTTP
+type Usage struct {
+	ID        uint      `json:"-"`
+	CreatedAt time.Time `json:"time"`
+	TokenID   uint      `json:"-" sql:"index"`
+	Request   string    `json:"request"`
+	IPAddr    string    `json:"ip"`
+}
+
+func (Usage) TableName() string { return "api_token_usages" }
+
+func hash(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func (t *Token) setScopeList() {
+	t.ScopeList = strings.Split(t.Scopes, ",")
+}
+
+//New creates a token for the given player. The returned string is the token
+//itself, which isn't stored and can't be retrieved later.
+func New(playerID uint, name string, scopes []string) (*Token, string, error) {
+	if name == "" {
+		return nil, "", errors.New("Token name cannot be empty")
+	}
+	if len(scopes) == 0 {
+		return nil, "", errors.New("Token needs at least one scope")
+	}
+	for _, scope := range scopes {
+		if !validScopes[scope] {
+			return nil, "", errors.New("Invalid scope " + scope)
+		}
+	}
+
+	var count int
+	db.DB.Model(&Token{}).Where("player_id = ? AND revoked = FALSE", playerID).Count(&count)
+	if count >= maxTokens {
+		return nil, "", ErrTooMany
+	}
+
+	bytes := make([]byte, 24)
+	if _, err := rand.Read(bytes); err != nil {
+		return nil, "", err
+	}
+	raw := prefix + hex.EncodeToString(bytes)
+
+	token := &Token{
+		PlayerID: playerID,
+		Name:     name,
+		Hint:     raw[:len(prefix)+4],
+		Hash:     hash(raw),
+		Scopes:   strings.Join(scopes, ","),
+	}
+	if err := db.DB.Create(token).Error; err != nil {
+		return nil, "", err
+	}
+
+	token.setScopeList()
+	return token, raw, nil
+}
+
+//Authenticate returns the token for the given raw token string
+func Authenticate(raw string) (*Token, error) {
+	if !strings.HasPrefix(raw, prefix) {
+		return nil, ErrInvalidToken
+	}
+
+	token := &Token{}
+	if db.DB.Where("hash = ? AND revoked = FALSE", hash(raw)).First(token).RecordNotFound() {
+		return nil, ErrInvalidToken
+	}
+
+	token.setScopeList()
+	return token, nil
+}
+
+//GetPlayerTokens returns all tokens the player hasn't revoked
+func GetPlayerTokens(playerID uint) []*Token {
+	tokens := []*Token{}
+	db.DB.Where("player_id = ? AND revoked = FALSE", playerID).Order("id").Find(&tokens)
+	for _, token := range tokens {
+		token.setScopeList()
+	}
+	return tokens
+}
+
+//Revoke revokes the player's token with the given ID
+func Revoke(playerID, id uint) error {
+	rows := db.DB.Model(&Token{}).Where("id = ? AND player_id = ? AND revoked = FALSE", id, playerID).
+		UpdateColumn("revoked", true).RowsAffected
+	if rows == 0 {
+		return errors.New("No such token")
+	}
+	return nil
+}
+
+//LogUsage records that the token with the given ID was used for request, from ipaddr
+func LogUsage(tokenID uint, request, ipaddr string) {
+	err := db.DB.Create(&Usage{TokenID: tokenID, Request: request, IPAddr: ipaddr}).Error
+	if err != nil {
+		logrus.Error(err)
+		return
+	}
+	db.DB.Model(&Token{}).Where("id = ?", tokenID).UpdateColumn("last_used_at", time.Now())
+}
+
+//GetUsage returns the most recent uses of the token
+func GetUsage(tokenID uint, limit int) []*Usage {
+	var usage []*Usage
+	db.DB.Where("token_id = ?", tokenID).Order("id desc").Limit(limit).Find(&usage)
+	return usage
+}
@@ -52,9 +52,30 @@ func NewInGameChatMessage(lobbyID uint, player *player.Player, message string) *
 func (m *ChatMessage) Save() { db.DB.Save(m) }
 
 func (m *ChatMessage) Send() {
-	broadcaster.SendMessageToRoom(fmt.Sprintf("%d_public", m.Room), "chatReceive", m)
+	var blockers []string
+	if !m.Bot {
+		blockers = player.GetBlockerSteamIDs(m.PlayerID)
+	}
+
+	rooms := []string{fmt.Sprintf("%d_public", m.Room)}
 	if m.Room != 0 {
-		broadcaster.SendMessageToRoom(fmt.Sprintf("%d_private", m.Room), "chatReceive", m)
+		rooms = append(rooms, fmt.Sprintf("%d_private", m.Room))
+	}
+
+	if len(blockers) == 0 {
+		for _, room := range rooms {
+			broadcaster.SendMessageToRoom(room, "chatReceive", m)
+		}
+		return
+	}
+
+	// players who blocked the sender don't get the message
+	skip := make(map[string]bool)
+	for _, steamid := range blockers {
+		skip[steamid] = true
+	}
+	for _, room := range rooms {
+		broadcaster.SendMessageToRoomSkip(room, "chatReceive", m, skip)
 	}
 }
 
@@ -138,11 +159,26 @@ func GetPlayerMessages(p *player.Player) ([]*ChatMessage, error) {
 
 }
 
-// Get a list of last 20 messages sent to room, used by frontend for displaying the chat history/scrollback
-func GetScrollback(room int) ([]*ChatMessage, error) {
+// Get a list of last 20 messages sent to room, used by frontend for displaying the chat history/scrollback.
+// Messages from players blocked by the player with the ID playerID are left out, playerID is 0 for logged out players.
+func GetScrollback(room int, playerID uint) ([]*ChatMessage, error) {
+	return GetHistory(room, playerID, 0, 20)
+}
+
+// GetHistory returns up to limit messages sent to room before the message with the ID before
+// (if it isn't 0), newest first. Clients page through the history by passing the ID of the oldest
+// message they have as before. Messages from players blocked by playerID are left out, like GetScrollback.
+func GetHistory(room int, playerID uint, before uint, limit int) ([]*ChatMessage, error) {
 	var messages []*ChatMessage // apparently the ORM works fine with using this type (they're aliases after all)
 
-	err := db.DB.Table("chat_messages").Where("room = ? AND deleted = FALSE", room).Order("id desc").Limit(20).Find(&messages).Error
+	query := db.DB.Table("chat_messages").Where("room = ? AND deleted = FALSE", room)
+	if before != 0 {
+		query = query.Where("id < ?", before)
+	}
+	if playerID != 0 {
+		query = query.Where("player_id NOT IN (SELECT blocked_id FROM player_blocks WHERE player_id = ?)", playerID)
+	}
+	err := query.Order("id desc").Limit(limit).Find(&messages).Error
 
 	return messages, err
 }
@@ -2,6 +2,7 @@ package chat_test
 
 import (
 	"strconv"
+	"time"
 	"testing"
 
 	db "github.com/TF2Stadium/Helen/database"
@@ -35,3 +36,154 @@ func TestNewChatMessage(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, len(messages), 3)
 }
+
+func TestMutePlayer(t *testing.T) {
+	player := testhelpers.CreatePlayer()
+	player.Save()
+
+	assert.Nil(t, GetMute(player.ID, 1))
+	assert.Equal(t, ErrMuteTime, MutePlayer(player.ID, 1, time.Now().Add(-time.Minute), "", 0))
+
+	err := MutePlayer(player.ID, 1, time.Now().Add(time.Hour), "spam", 0)
+	assert.NoError(t, err)
+	mute := GetMute(player.ID, 1)
+	if assert.NotNil(t, mute) {
+		assert.Equal(t, "spam", mute.Reason)
+	}
+	// mutes are scoped to the room
+	assert.Nil(t, GetMute(player.ID, 0))
+
+	// only the player's own mutes are lifted by UnmutePlayerBy
+	assert.NoError(t, UnmutePlayerBy(player.ID, 1, 42))
+	assert.NotNil(t, GetMute(player.ID, 1))
+
+	assert.NoError(t, UnmutePlayer(player.ID, 1))
+	assert.Nil(t, GetMute(player.ID, 1))
+}
+
+func TestSlowMode(t *testing.T) {
+	player := testhelpers.CreatePlayer()
+	player.Save()
+
+	assert.NoError(t, SetSlowMode(2, 30*time.Second))
+	assert.Equal(t, 30*time.Second, GetSlowMode(2))
+	assert.Zero(t, SlowModeWait(player.ID, 2))
+
+	db.DB.Save(NewChatMessage("hi", 2, player))
+	assert.NotZero(t, SlowModeWait(player.ID, 2))
+
+	assert.NoError(t, SetSlowMode(2, 0))
+	assert.Zero(t, GetSlowMode(2))
+	assert.Zero(t, SlowModeWait(player.ID, 2))
+}
+
+func TestDirectMessages(t *testing.T) {
+	p := testhelpers.CreatePlayer()
+	other := testhelpers.CreatePlayer()
+
+	_, err := SendDirectMessage(p, p, "hi")
+	assert.Equal(t, ErrDirectSelf, err)
+
+	for i := 0; i < 3; i++ {
+		_, err := SendDirectMessage(p, other, strconv.Itoa(i))
+		assert.NoError(t, err)
+	}
+	_, err = SendDirectMessage(other, p, "hey")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 3, UnreadCount(other.ID))
+	assert.Equal(t, 1, UnreadCount(p.ID))
+
+	conversations := GetConversations(other.ID)
+	if assert.Len(t, conversations, 1) {
+		assert.Equal(t, p.SteamID, conversations[0].Player.SteamID)
+		assert.Equal(t, "hey", conversations[0].Last.Message)
+		assert.Equal(t, p.SteamID, conversations[0].Last.Sender.SteamID)
+		assert.Equal(t, 3, conversations[0].Unread)
+	}
+
+	messages := GetConversation(p.ID, other.ID, 0, 2)
+	if assert.Len(t, messages, 2) {
+		assert.Equal(t, "hey", messages[0].Message)
+		assert.Equal(t, other.SteamID, messages[0].Sender.SteamID)
+		assert.Equal(t, p.SteamID, messages[0].Recipient.SteamID)
+		assert.Len(t, GetConversation(p.ID, other.ID, messages[1].ID, 10), 2)
+	}
+
+	assert.NoError(t, MarkRead(other.ID, p.ID))
+	assert.Zero(t, UnreadCount(other.ID))
+	assert.Equal(t, 1, UnreadCount(p.ID))
+
+	other.Block(p)
+	_, err = SendDirectMessage(p, other, "hello?")
+	assert.Equal(t, ErrDirectBlocked, err)
+}
+
+func TestScrollbackBlocked(t *testing.T) {
+	p := testhelpers.CreatePlayer()
+	other := testhelpers.CreatePlayer()
+
+	db.DB.Save(NewChatMessage("hello", 3, p))
+	db.DB.Save(NewChatMessage("hi", 3, other))
+	p.Block(other)
+
+	messages, err := GetScrollback(3, 0)
+	assert.NoError(t, err)
+	assert.Len(t, messages, 2)
+
+	messages, err = GetScrollback(3, p.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, messages, 1) {
+		assert.Equal(t, "hello", messages[0].Message)
+	}
+}
+
+func TestGetHistory(t *testing.T) {
+	player := testhelpers.CreatePlayer()
+	for i := 0; i < 5; i++ {
+		db.DB.Save(NewChatMessage(strconv.Itoa(i), 4, player))
+	}
+
+	messages, err := GetHistory(4, 0, 0, 3)
+	assert.NoError(t, err)
+	if assert.Len(t, messages, 3) {
+		assert.Equal(t, "4", messages[0].Message)
+
+		older, err := GetHistory(4, 0, messages[2].ID, 3)
+		assert.NoError(t, err)
+		if assert.Len(t, older, 2) {
+			assert.Equal(t, "1", older[0].Message)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	player := testhelpers.CreatePlayer()
+	other := testhelpers.CreatePlayer()
+	db.DB.Save(NewChatMessage("anyone up for a pug tonight", 5, player))
+	db.DB.Save(NewChatMessage("no pugs today", 6, other))
+	db.DB.Save(NewChatMessage("join my PUG", 6, player))
+
+	_, _, err := Search(SearchFilter{Query: " ", Room: -1}, 0, 10)
+	assert.Equal(t, ErrEmptySearch, err)
+
+	results, total, err := Search(SearchFilter{Query: "pug", Room: -1}, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, total)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, "join my PUG", results[0].Message)
+		assert.Contains(t, string(results[0].Headline), "<mark>PUG</mark>")
+	}
+
+	_, total, _ = Search(SearchFilter{Query: "pug", Room: 5}, 0, 10)
+	assert.Equal(t, 1, total)
+	_, total, _ = Search(SearchFilter{Query: "pug", Room: -1, PlayerID: other.ID}, 0, 10)
+	assert.Zero(t, total)
+
+	context, err := GetContext(results[0].ID, 5)
+	assert.NoError(t, err)
+	if assert.Len(t, context, 2) {
+		assert.Equal(t, "no pugs today", context[0].Message)
+		assert.Equal(t, results[0].ID, context[1].ID)
+	}
+}
@@ -0,0 +1,142 @@
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/TF2Stadium/Helen/controllers/broadcaster"
+	db "github.com/TF2Stadium/Helen/database"
+	"github.com/TF2Stadium/Helen/models/player"
+)
+
+//DirectMessage is a private message sent from one player to another
+type DirectMessage struct {
+	ID        uint
+	CreatedAt time.Time
+
+	Sender      player.Player `json:"-"`
+	SenderID    uint          `sql:"index"`
+	Recipient   player.Player `json:"-"`
+	RecipientID uint          `sql:"index"`
+	Message     string        `sql:"type:varchar(300)"`
+	ReadAt      *time.Time
+}
+
+//Conversation is a summary of the direct messages between the player and another player
+type Conversation struct {
+	Player minPlayer      `json:"player"`
+	Last   *DirectMessage `json:"last"`
+	Unread int            `json:"unread"`
+}
+
+var (
+	ErrDirectSelf    = errors.New("You can't send messages to yourself")
+	ErrDirectBlocked = errors.New("This player isn't accepting messages from you")
+)
+
+func newMinPlayer(p *player.Player) minPlayer {
+	return minPlayer{p.Alias(), p.SteamID, p.DecoratePlayerTags()}
+}
+
+//MarshalJSON needs the Sender and Recipient to be loaded
+func (m *DirectMessage) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"id":        m.ID,
+		"timestamp": m.CreatedAt,
+		"from":      newMinPlayer(&m.Sender),
+		"to":        newMinPlayer(&m.Recipient),
+		"message":   m.Message,
+		"read":      m.ReadAt != nil,
+	})
+}
+
+//SendDirectMessage saves a message from sender to recipient, and sends it
+//to all of their sockets
+func SendDirectMessage(sender, recipient *player.Player, message string) (*DirectMessage, error) {
+	if sender.ID == recipient.ID {
+		return nil, ErrDirectSelf
+	}
+	if recipient.HasBlocked(sender.ID) {
+		return nil, ErrDirectBlocked
+	}
+
+	m := &DirectMessage{SenderID: sender.ID, RecipientID: recipient.ID, Message: message}
+	if err := db.DB.Create(m).Error; err != nil {
+		return nil, err
+	}
+	// set after creating the message, so gorm doesn't save the players too
+	m.Sender, m.Recipient = *sender, *recipient
+
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	broadcaster.SendMessage(recipient.SteamID, "directMessage", json.RawMessage(raw))
+	broadcaster.SendMessage(sender.SteamID, "directMessage", json.RawMessage(raw))
+	return m, nil
+}
+
+//GetConversations returns the player's conversations, most recently active first
+func GetConversations(playerID uint) []*Conversation {
+	rows, err := db.DB.Raw(`SELECT CASE WHEN sender_id = ? THEN recipient_id ELSE sender_id END AS other,
+MAX(id), COUNT(CASE WHEN recipient_id = ? AND read_at IS NULL THEN 1 END)
+FROM direct_messages WHERE sender_id = ? OR recipient_id = ?
+GROUP BY other ORDER BY MAX(id) DESC`, playerID, playerID, playerID, playerID).Rows()
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var self player.Player
+	db.DB.First(&self, playerID)
+
+	var list []*Conversation
+	for rows.Next() {
+		var other, last uint
+		var unread int
+		rows.Scan(&other, &last, &unread)
+
+		var p player.Player
+		db.DB.First(&p, other)
+		c := &Conversation{Player: newMinPlayer(&p), Last: &DirectMessage{}, Unread: unread}
+		db.DB.First(c.Last, last)
+		if c.Last.SenderID == playerID {
+			c.Last.Sender, c.Last.Recipient = self, p
+		} else {
+			c.Last.Sender, c.Last.Recipient = p, self
+		}
+		list = append(list, c)
+	}
+
+	return list
+}
+
+//GetConversation returns up to limit messages between the two players, sent
+//before the message with the ID before (if it isn't 0), newest first
+func GetConversation(playerID, otherID uint, before uint, limit int) []*DirectMessage {
+	var messages []*DirectMessage
+
+	query := db.DB.Where("(sender_id = ? AND recipient_id = ?) OR (sender_id = ? AND recipient_id = ?)",
+		playerID, otherID, otherID, playerID)
+	if before != 0 {
+		query = query.Where("id < ?", before)
+	}
+	query.Preload("Sender").Preload("Recipient").Order("id desc").Limit(limit).Find(&messages)
+
+	return messages
+}
+
+//MarkRead marks all messages sent from otherID to playerID as read
+func MarkRead(playerID, otherID uint) error {
+	return db.DB.Model(&DirectMessage{}).
+		Where("recipient_id = ? AND sender_id = ? AND read_at IS NULL", playerID, otherID).
+		Update("read_at", time.Now()).Error
+}
+
+//UnreadCount returns the number of unread direct messages sent to the player
+func UnreadCount(playerID uint) int {
+	var count int
+	db.DB.Model(&DirectMessage{}).Where("recipient_id = ? AND read_at IS NULL", playerID).Count(&count)
+	return count
+}
@@ -0,0 +1,50 @@
+package chat
+
+import (
+	"regexp"
+	"strings"
+
+	db "github.com/TF2Stadium/Helen/database"
+	"github.com/TF2Stadium/Helen/models/player"
+)
+
+//maximum number of players notified for a single message
+const maxMentions = 5
+
+var reMention = regexp.MustCompile(`(?:^|\s)@([^\s@]{1,32})`)
+
+//ParseMentions returns the lowercased names mentioned in message with @name
+func ParseMentions(message string) []string {
+	var names []string
+	seen := make(map[string]bool)
+
+	for _, match := range reMention.FindAllStringSubmatch(message, -1) {
+		// "@name," and "@name?" mention name
+		name := strings.ToLower(strings.TrimRight(match[1], ",.!?:;"))
+		if name == "" || seen[name] {
+			continue
+		}
+
+		seen[name] = true
+		names = append(names, name)
+		if len(names) == maxMentions {
+			break
+		}
+	}
+
+	return names
+}
+
+//GetMentioned returns the players whose Steam name or site alias is one of
+//the names mentioned in message
+func GetMentioned(message string) []*player.Player {
+	names := ParseMentions(message)
+	if len(names) == 0 {
+		return nil
+	}
+
+	var players []*player.Player
+	db.DB.Where("lower(name) IN (?) OR lower(settings -> 'siteAlias') IN (?)", names, names).
+		Limit(maxMentions * 2).Find(&players)
+	return players
+}
@@ -0,0 +1,16 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMentions(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, ParseMentions("no mentions here"))
+	assert.Empty(t, ParseMentions("mail me at someone@example.com"))
+	assert.Equal(t, []string{"foo", "bar"}, ParseMentions("@Foo and @bar, @FOO again"))
+	assert.Len(t, ParseMentions("@a @b @c @d @e @f @g"), maxMentions)
+}
@@ -0,0 +1,252 @@
+// Copyright (C) 2015  TF2Stadium
+// Use of this source code is governed by the GPLv3
+// that can be found in the COPYING file.
+
+package chat
+
+import (
+	"errors"
+	"regexp"
+	"sync"
+	"time"
+
+	db "github.com/TF2Stadium/Helen/database"
+	"github.com/sirupsen/logrus"
+)
+
+//Mute stops a player from chatting in a room till Until
+type Mute struct {
+	ID        uint      `json:"-"`
+	CreatedAt time.Time `json:"-"`
+
+	PlayerID uint      `json:"-" sql:"index"`
+	Room     int       `json:"room"`
+	Until    time.Time `json:"until"`
+	Reason   string    `json:"reason"`
+	MutedBy  uint      `json:"-"`
+}
+
+func (Mute) TableName() string { return "chat_mutes" }
+
+//SlowMode limits how often players can send messages in a room
+type SlowMode struct {
+	ID       uint
+	Room     int `sql:"not null;unique"`
+	Interval int // seconds between messages
+}
+
+func (SlowMode) TableName() string { return "chat_slow_modes" }
+
+type FilterAction string
+
+const (
+	FilterMask  FilterAction = "mask"  // replace matches with <redacted>
+	FilterBlock FilterAction = "block" // don't send the message
+	FilterMute  FilterAction = "mute"  // don't send the message, and mute the player in the room
+)
+
+//Filter is a regular expression messages are checked against
+type Filter struct {
+	ID        uint
+	CreatedAt time.Time
+
+	Pattern string `sql:"not null;unique"`
+	Action  FilterAction
+	MuteFor int // minutes, for FilterMute
+	Note    string
+}
+
+func (Filter) TableName() string { return "chat_filters" }
+
+type filter struct {
+	re *regexp.Regexp
+	*Filter
+}
+
+var (
+	ErrFilterAction = errors.New("Invalid filter action")
+	ErrMuteTime     = errors.New("Mutes have to end in the future")
+
+	mu        = new(sync.RWMutex)
+	filters   []filter
+	slowModes = make(map[int]time.Duration)
+)
+
+//Reload loads filters and slow modes from the database, needs to be
+//called after they're changed
+func Reload() {
+	var rows []*Filter
+	db.DB.Order("id").Find(&rows)
+
+	var list []filter
+	for _, row := range rows {
+		re, err := regexp.Compile(row.Pattern)
+		if err != nil {
+			logrus.Errorf("Invalid chat filter %s: %v", row.Pattern, err)
+			continue
+		}
+		list = append(list, filter{re, row})
+	}
+
+	var modes []*SlowMode
+	db.DB.Find(&modes)
+	intervals := make(map[int]time.Duration)
+	for _, mode := range modes {
+		intervals[mode.Room] = time.Duration(mode.Interval) * time.Second
+	}
+
+	mu.Lock()
+	filters = list
+	slowModes = intervals
+	mu.Unlock()
+}
+
+//applyFilters checks message against list. Messages matching a block or mute
+//filter return that filter, matches of mask filters are replaced with <redacted>.
+func applyFilters(list []filter, message string) (string, *Filter) {
+	var stop *Filter
+	for _, f := range list {
+		if !f.re.MatchString(message) {
+			continue
+		}
+
+		switch f.Action {
+		case FilterMask:
+			message = f.re.ReplaceAllString(message, "<redacted>")
+		case FilterMute:
+			return message, f.Filter
+		case FilterBlock:
+			if stop == nil {
+				stop = f.Filter
+			}
+		}
+	}
+
+	return message, stop
+}
+
+//ApplyFilters checks message against filters, returns the masked message, and
+//the filter that stops the message from being sent (if any)
+func ApplyFilters(message string) (string, *Filter) {
+	mu.RLock()
+	defer mu.RUnlock()
+	return applyFilters(filters, message)
+}
+
+//AddFilter adds a filter, and reloads filters
+func AddFilter(pattern string, action FilterAction, muteFor int, note string) error {
+	if _, err := regexp.Compile(pattern); err != nil {
+		return err
+	}
+	if action != FilterMask && action != FilterBlock && action != FilterMute {
+		return ErrFilterAction
+	}
+
+	err := db.DB.Create(&Filter{Pattern: pattern, Action: action, MuteFor: muteFor, Note: note}).Error
+	if err != nil {
+		return err
+	}
+
+	Reload()
+	return nil
+}
+
+//RemoveFilter removes the filter with the given ID, and reloads filters
+func RemoveFilter(id uint) {
+	db.DB.Where("id = ?", id).Delete(&Filter{})
+	Reload()
+}
+
+//GetFilters returns every filter
+func GetFilters() (list []*Filter) {
+	db.DB.Order("id").Find(&list)
+	return
+}
+
+//SetSlowMode sets the interval between messages in room, 0 disables slow mode
+func SetSlowMode(room int, interval time.Duration) error {
+	var err error
+	if interval <= 0 {
+		err = db.DB.Where("room = ?", room).Delete(&SlowMode{}).Error
+	} else {
+		mode := &SlowMode{}
+		db.DB.Where("room = ?", room).FirstOrInit(mode)
+		mode.Room = room
+		mode.Interval = int(interval / time.Second)
+		err = db.DB.Save(mode).Error
+	}
+
+	Reload()
+	return err
+}
+
+//GetSlowMode returns the interval between messages in room, 0 if slow mode is off
+func GetSlowMode(room int) time.Duration {
+	mu.RLock()
+	defer mu.RUnlock()
+	return slowModes[room]
+}
+
+//GetSlowModes returns the rooms where slow mode is on
+func GetSlowModes() (list []*SlowMode) {
+	db.DB.Order("room").Find(&list)
+	return
+}
+
+//SlowModeWait returns how long the player has to wait till they can send
+//another message in room, 0 if they can send one now
+func SlowModeWait(playerID uint, room int) time.Duration {
+	interval := GetSlowMode(room)
+	if interval == 0 {
+		return 0
+	}
+
+	last := &ChatMessage{}
+	err := db.DB.Where("player_id = ? AND room = ? AND bot = FALSE", playerID, room).Order("id desc").First(last).Error
+	if err != nil {
+		return 0
+	}
+
+	wait := interval - time.Since(last.CreatedAt)
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+//MutePlayer mutes the player in room till until, by is the ID of the
+//player who muted them (0 for filters)
+func MutePlayer(playerID uint, room int, until time.Time, reason string, by uint) error {
+	if until.Before(time.Now()) {
+		return ErrMuteTime
+	}
+
+	return db.DB.Create(&Mute{PlayerID: playerID, Room: room, Until: until, Reason: reason, MutedBy: by}).Error
+}
+
+//UnmutePlayer lifts the player's mutes in room
+func UnmutePlayer(playerID uint, room int) error {
+	return db.DB.Where("player_id = ? AND room = ? AND until > now()", playerID, room).Delete(&Mute{}).Error
+}
+
+//UnmutePlayerBy lifts the player's mutes in room made by the player with the ID by,
+//leaving mutes made by others and by filters in place
+func UnmutePlayerBy(playerID uint, room int, by uint) error {
+	return db.DB.Where("player_id = ? AND room = ? AND muted_by = ? AND until > now()", playerID, room, by).Delete(&Mute{}).Error
+}
+
+//GetMute returns the player's active mute in room, or nil if they aren't muted
+func GetMute(playerID uint, room int) *Mute {
+	mute := &Mute{}
+	err := db.DB.Where("player_id = ? AND room = ? AND until > now()", playerID, room).Order("until desc").First(mute).Error
+	if err != nil {
+		return nil
+	}
+	return mute
+}
+
+//GetActiveMutes returns every active mute, ending soonest first
+func GetActiveMutes() (list []*Mute) {
+	db.DB.Where("until > now()").Order("until").Find(&list)
+	return
+}
@@ -0,0 +1,40 @@
+package chat
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testFilter(pattern string, action FilterAction) filter {
+	return filter{regexp.MustCompile(pattern), &Filter{Pattern: pattern, Action: action}}
+}
+
+func TestApplyFilters(t *testing.T) {
+	t.Parallel()
+	list := []filter{
+		testFilter(`(?i)\bfoo\b`, FilterMask),
+		testFilter(`(?i)spam\.com`, FilterBlock),
+		testFilter(`(?i)\bslur\b`, FilterMute),
+	}
+
+	msg, f := applyFilters(list, "hello world")
+	assert.Equal(t, "hello world", msg)
+	assert.Nil(t, f)
+
+	msg, f = applyFilters(list, "Foo bar foo")
+	assert.Equal(t, "<redacted> bar <redacted>", msg)
+	assert.Nil(t, f)
+
+	_, f = applyFilters(list, "join spam.com")
+	if assert.NotNil(t, f) {
+		assert.Equal(t, FilterBlock, f.Action)
+	}
+
+	// mute filters win over block filters
+	_, f = applyFilters(list, "spam.com slur")
+	if assert.NotNil(t, f) {
+		assert.Equal(t, FilterMute, f.Action)
+	}
+}
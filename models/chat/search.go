@@ -0,0 +1,121 @@
+package chat
+
+import (
+	"errors"
+	"html"
+	"html/template"
+	"strings"
+	"time"
+
+	db "github.com/TF2Stadium/Helen/database"
+	"github.com/jinzhu/gorm"
+)
+
+//markers ts_headline puts around matches, replaced with <mark> after the
+//message is escaped. The worst a message containing them can do is add
+//highlighting of its own.
+const (
+	startMark = "\x02"
+	stopMark  = "\x03"
+
+	headlineOptions = "StartSel=" + startMark + ", StopSel=" + stopMark + ", HighlightAll=TRUE"
+)
+
+var ErrEmptySearch = errors.New("Search query can't be empty")
+
+//SearchFilter narrows down a full-text search of chat messages
+type SearchFilter struct {
+	Query    string // words to search for, in plain text
+	Room     int    // -1 for all rooms
+	PlayerID uint
+	Since    time.Time
+	Until    time.Time
+}
+
+//SearchResult is a message matching a search, with the matches highlighted
+type SearchResult struct {
+	*ChatMessage
+	Headline template.HTML
+}
+
+// search uses the 'simple' configuration, since messages are in many languages
+func (f SearchFilter) query() *gorm.DB {
+	query := db.DB.Model(&ChatMessage{}).
+		Where("to_tsvector('simple', message) @@ plainto_tsquery('simple', ?)", f.Query)
+	if f.Room != -1 {
+		query = query.Where("room = ?", f.Room)
+	}
+	if f.PlayerID != 0 {
+		query = query.Where("player_id = ?", f.PlayerID)
+	}
+	if !f.Since.IsZero() {
+		query = query.Where("created_at >= ?", f.Since)
+	}
+	if !f.Until.IsZero() {
+		query = query.Where("created_at < ?", f.Until)
+	}
+	return query
+}
+
+//highlight escapes headline, and marks the matches ts_headline found in it
+func highlight(headline string) template.HTML {
+	escaped := html.EscapeString(headline)
+	escaped = strings.Replace(escaped, startMark, "<mark>", -1)
+	escaped = strings.Replace(escaped, stopMark, "</mark>", -1)
+	return template.HTML(escaped)
+}
+
+//Search returns up to limit messages matching filter, skipping the first
+//offset results, newest first. The second return value is the total number
+//of matching messages.
+func Search(filter SearchFilter, offset, limit int) ([]*SearchResult, int, error) {
+	filter.Query = strings.TrimSpace(filter.Query)
+	if filter.Query == "" {
+		return nil, 0, ErrEmptySearch
+	}
+
+	var total int
+	if err := filter.query().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := filter.query().
+		Select("id, ts_headline('simple', message, plainto_tsquery('simple', ?), ?)", filter.Query, headlineOptions).
+		Order("id desc").Offset(offset).Limit(limit).Rows()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var results []*SearchResult
+	for rows.Next() {
+		var id uint
+		var headline string
+		rows.Scan(&id, &headline)
+
+		message := &ChatMessage{}
+		db.DB.Preload("Player").First(message, id)
+		results = append(results, &SearchResult{message, highlight(headline)})
+	}
+
+	return results, total, nil
+}
+
+//GetContext returns the message with the given ID, and up to n messages sent
+//to the same room before and after it, oldest first
+func GetContext(id uint, n int) ([]*ChatMessage, error) {
+	message := &ChatMessage{}
+	if err := db.DB.First(message, id).Error; err != nil {
+		return nil, err
+	}
+
+	var before, after []*ChatMessage
+	db.DB.Preload("Player").Where("room = ? AND id < ?", message.Room, id).Order("id desc").Limit(n).Find(&before)
+	db.DB.Preload("Player").Where("room = ? AND id >= ?", message.Room, id).Order("id").Limit(n + 1).Find(&after)
+
+	messages := make([]*ChatMessage, 0, len(before)+len(after))
+	for i := len(before) - 1; i >= 0; i-- {
+		messages = append(messages, before[i])
+	}
+	return append(messages, after...), nil
+}
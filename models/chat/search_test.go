@@ -0,0 +1,16 @@
+package chat
+
+import (
+	"html/template"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlight(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, template.HTML("no matches"), highlight("no matches"))
+	assert.Equal(t, template.HTML("<mark>gg</mark> &lt;script&gt; <mark>gg</mark>"),
+		highlight(startMark+"gg"+stopMark+" <script> "+startMark+"gg"+stopMark))
+}
@@ -0,0 +1,195 @@
+// Copyright (C) 2015  TF2Stadium
+// Use of this source code is governed by the GPLv3
+// that can be found in the COPYING file.
+
+package demo
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/TF2Stadium/Helen/config"
+	db "github.com/TF2Stadium/Helen/database"
+)
+
+//Demo is an STV demo of a lobby, kept in the configured Storage
+type Demo struct {
+	ID        uint      `json:"id"`
+	CreatedAt time.Time `json:"downloadedAt"` // when the demo was downloaded from the server
+
+	LobbyID    uint   `json:"lobbyID" sql:"index"`
+	MapName    string `json:"map"`
+	FileName   string `json:"-"`          // name of the file in storage
+	Size       int64  `json:"size"`       // size of the stored file, in bytes
+	Checksum   string `json:"sha256"`     // SHA-256 checksum of the uncompressed demo
+	Compressed bool   `json:"compressed"` // true if the file is gzipped
+
+	URL string `sql:"-" json:"url"`
+}
+
+//DemoPlayer links a demo to a player who played in it's lobby
+type DemoPlayer struct {
+	ID       uint
+	DemoID   uint `sql:"index"`
+	PlayerID uint `sql:"index"`
+}
+
+//SetURL sets the URL the demo can be downloaded from, which
+//expires after config.Constants.DemosURLExpiry
+func (d *Demo) SetURL() {
+	url, err := store.URL(d.FileName, config.Constants.DemosURLExpiry)
+	if err != nil {
+		logrus.Error(err)
+		return
+	}
+	d.URL = url
+}
+
+//Archive compresses the demo file at path, puts it in storage and adds it to
+//the demo index. The uncompressed file is removed.
+func Archive(lobbyID uint, mapName string, path string, playerIDs []uint) (*Demo, error) {
+	demo := &Demo{
+		LobbyID:    lobbyID,
+		MapName:    mapName,
+		FileName:   filepath.Base(path) + ".gz",
+		Compressed: true,
+	}
+
+	tmp := filepath.Join(os.TempDir(), fmt.Sprintf("helen_%d_%s", time.Now().UnixNano(), demo.FileName))
+	defer os.Remove(tmp)
+
+	checksum, size, err := compress(path, tmp)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(tmp)
+	if err != nil {
+		return nil, err
+	}
+	err = store.Put(demo.FileName, file)
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(path)
+
+	demo.Checksum = checksum
+	demo.Size = size
+	if err := db.DB.Create(demo).Error; err != nil {
+		return nil, err
+	}
+
+	for _, id := range playerIDs {
+		db.DB.Create(&DemoPlayer{DemoID: demo.ID, PlayerID: id})
+	}
+
+	demo.SetURL()
+	return demo, nil
+}
+
+//compress gzips the file src to dst, returning the SHA-256 checksum of
+//src and the size of dst
+func compress(src, dst string) (checksum string, size int64, err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return
+	}
+	defer out.Close()
+
+	hash := sha256.New()
+	writer := gzip.NewWriter(out)
+	if _, err = io.Copy(writer, io.TeeReader(in, hash)); err != nil {
+		os.Remove(dst)
+		return
+	}
+	if err = writer.Close(); err != nil {
+		os.Remove(dst)
+		return
+	}
+
+	info, err := out.Stat()
+	if err != nil {
+		return
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), info.Size(), nil
+}
+
+//Delete deletes the demo's file and removes it from the index
+func (d *Demo) Delete() error {
+	if err := store.Delete(d.FileName); err != nil {
+		return err
+	}
+
+	db.DB.Where("demo_id = ?", d.ID).Delete(&DemoPlayer{})
+	return db.DB.Delete(d).Error
+}
+
+func setURLs(demos []*Demo) []*Demo {
+	for _, demo := range demos {
+		demo.SetURL()
+	}
+	return demos
+}
+
+//GetDemo returns the demo with the given ID
+func GetDemo(id uint) (*Demo, error) {
+	demo := &Demo{}
+	err := db.DB.First(demo, id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	demo.SetURL()
+	return demo, nil
+}
+
+//GetLobbyDemos returns the demos recorded for the given lobby
+func GetLobbyDemos(lobbyID uint) []*Demo {
+	var demos []*Demo
+	db.DB.Where("lobby_id = ?", lobbyID).Order("id desc").Find(&demos)
+	return setURLs(demos)
+}
+
+//GetPlayerDemos returns the latest demos of lobbies the player has played in,
+//starting from the demo with ID before (if it isn't 0)
+func GetPlayerDemos(playerID uint, before uint, limit int) []*Demo {
+	var demos []*Demo
+
+	query := db.DB.Model(&Demo{}).Joins("INNER JOIN demo_players ON demo_players.demo_id = demos.id").
+		Where("demo_players.player_id = ?", playerID)
+	if before != 0 {
+		query = query.Where("demos.id < ?", before)
+	}
+	query.Order("demos.id desc").Limit(limit).Find(&demos)
+
+	return setURLs(demos)
+}
+
+//GetAllDemos returns all demos in the index, latest first
+func GetAllDemos(offset, limit int) []*Demo {
+	var demos []*Demo
+	db.DB.Order("id desc").Offset(offset).Limit(limit).Find(&demos)
+	return setURLs(demos)
+}
+
+//TotalSize returns the total size of all stored demos, in bytes
+func TotalSize() int64 {
+	var size int64
+	db.DB.DB().QueryRow("SELECT COALESCE(SUM(size), 0) FROM demos").Scan(&size)
+	return size
+}
@@ -0,0 +1,80 @@
+// Copyright (C) 2015  TF2Stadium
+// Use of this source code is governed by the GPLv3
+// that can be found in the COPYING file.
+
+package demo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	db "github.com/TF2Stadium/Helen/database"
+)
+
+//demos downloaded before they were indexed, named <lobby id>.dem
+var reUnindexed = regexp.MustCompile(`^(\d+)\.dem$`)
+
+//MigrateFolder moves the demos in folder to the current storage backend.
+//Demos which haven't been indexed yet are compressed and indexed first.
+func MigrateFolder(folder string) error {
+	files, err := ioutil.ReadDir(folder)
+	if err != nil {
+		return err
+	}
+
+	var moved int
+	for _, info := range files {
+		name := info.Name()
+		path := filepath.Join(folder, name)
+		if info.IsDir() {
+			continue
+		}
+
+		if matches := reUnindexed.FindStringSubmatch(name); matches != nil {
+			lobbyID, _ := strconv.ParseUint(matches[1], 10, 32)
+
+			var mapName string
+			var playerIDs []uint
+			db.DB.Table("lobbies").Where("id = ?", lobbyID).Select("map_name").Row().Scan(&mapName)
+			db.DB.Table("lobby_slots").Where("lobby_id = ?", lobbyID).Pluck("player_id", &playerIDs)
+
+			if _, err := Archive(uint(lobbyID), mapName, path, playerIDs); err != nil {
+				logrus.Errorf("Couldn't migrate %s: %v", name, err)
+				continue
+			}
+			moved++
+			continue
+		}
+
+		var count int
+		db.DB.Model(&Demo{}).Where("file_name = ?", name).Count(&count)
+		if count == 0 {
+			continue
+		}
+		if local, ok := store.(*LocalStorage); ok && filepath.Clean(local.path(name)) == filepath.Clean(path) {
+			continue // already where it should be
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			logrus.Errorf("Couldn't migrate %s: %v", name, err)
+			continue
+		}
+		err = store.Put(name, file)
+		file.Close()
+		if err != nil {
+			logrus.Errorf("Couldn't migrate %s: %v", name, err)
+			continue
+		}
+
+		os.Remove(path)
+		moved++
+	}
+
+	logrus.Infof("Migrated %d demos from %s", moved, folder)
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright (C) 2015  TF2Stadium
+// Use of this source code is governed by the GPLv3
+// that can be found in the COPYING file.
+
+package demo
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/TF2Stadium/Helen/config"
+	db "github.com/TF2Stadium/Helen/database"
+)
+
+//expired returns the demos that should be deleted, either because they are older than
+//maxAge, or because the total size of demos is over quota. demos must be sorted oldest first,
+//and the oldest are deleted first. A zero maxAge or quota disables that limit.
+func expired(demos []*Demo, now time.Time, maxAge time.Duration, quota int64) []*Demo {
+	var total int64
+	for _, demo := range demos {
+		total += demo.Size
+	}
+
+	var expired []*Demo
+	for _, demo := range demos {
+		tooOld := maxAge != 0 && now.Sub(demo.CreatedAt) > maxAge
+		overQuota := quota != 0 && total > quota
+		if !tooOld && !overQuota {
+			break
+		}
+
+		expired = append(expired, demo)
+		total -= demo.Size
+	}
+
+	return expired
+}
+
+//ApplyRetention deletes demos which are older than config.Constants.DemosMaxAge,
+//and the oldest demos while the total size is over config.Constants.DemosQuota
+func ApplyRetention() {
+	var demos []*Demo
+	db.DB.Order("id asc").Find(&demos)
+
+	quota := config.Constants.DemosQuota * 1024 * 1024
+	for _, demo := range expired(demos, time.Now(), config.Constants.DemosMaxAge, quota) {
+		if err := demo.Delete(); err != nil {
+			logrus.Error(err)
+			continue
+		}
+		logrus.Infof("Deleted demo for lobby #%d (%s)", demo.LobbyID, demo.FileName)
+	}
+}
+
+//StartRetention applies the retention policy every hour, in a goroutine
+func StartRetention() {
+	go func() {
+		for {
+			ApplyRetention()
+			time.Sleep(time.Hour)
+		}
+	}()
+}
@@ -0,0 +1,32 @@
+package demo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpired(t *testing.T) {
+	now := time.Now()
+	demos := []*Demo{
+		{ID: 1, CreatedAt: now.Add(-72 * time.Hour), Size: 100},
+		{ID: 2, CreatedAt: now.Add(-48 * time.Hour), Size: 100},
+		{ID: 3, CreatedAt: now.Add(-24 * time.Hour), Size: 100},
+		{ID: 4, CreatedAt: now, Size: 100},
+	}
+
+	assert.Empty(t, expired(demos, now, 0, 0))
+
+	old := expired(demos, now, 36*time.Hour, 0)
+	if assert.Len(t, old, 2) {
+		assert.Equal(t, uint(1), old[0].ID)
+		assert.Equal(t, uint(2), old[1].ID)
+	}
+
+	over := expired(demos, now, 0, 150)
+	assert.Len(t, over, 3)
+
+	both := expired(demos, now, 60*time.Hour, 350)
+	assert.Len(t, both, 1)
+}
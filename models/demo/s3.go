@@ -0,0 +1,235 @@
+// Copyright (C) 2015  TF2Stadium
+// Use of this source code is governed by the GPLv3
+// that can be found in the COPYING file.
+
+package demo
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//S3Storage stores demos in a bucket on an S3 compatible service
+//(AWS, MinIO, etc), requests are signed with AWS Signature Version 4.
+type S3Storage struct {
+	Endpoint  string // host[:port] of the service, like s3.amazonaws.com or localhost:9000
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	Secure    bool // use https
+	PathStyle bool // use endpoint/bucket/key instead of bucket.endpoint/key, needed for MinIO
+
+	Client *http.Client
+}
+
+const (
+	amzDateFormat  = "20060102T150405Z"
+	amzShortFormat = "20060102"
+	maxPresign     = 7 * 24 * time.Hour // longest expiry S3 allows for presigned URLs
+)
+
+func (s *S3Storage) client() *http.Client {
+	if s.Client != nil {
+		return s.Client
+	}
+	return http.DefaultClient
+}
+
+//objectURL returns the URL of the object with the given name
+func (s *S3Storage) objectURL(name string) *url.URL {
+	u := &url.URL{Scheme: "http"}
+	if s.Secure {
+		u.Scheme = "https"
+	}
+
+	if s.PathStyle {
+		u.Host = s.Endpoint
+		u.Path = "/" + s.Bucket + "/" + name
+	} else {
+		u.Host = s.Bucket + "." + s.Endpoint
+		u.Path = "/" + name
+	}
+	u.RawPath = uriEncode(u.Path, false)
+	return u
+}
+
+//uriEncode encodes s as described in the SigV4 spec, only unreserved
+//characters are left as they are.
+func uriEncode(s string, encodeSlash bool) string {
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			buf.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			buf.WriteByte(c)
+		default:
+			fmt.Fprintf(&buf, "%%%02X", c)
+		}
+	}
+	return buf.String()
+}
+
+func canonicalQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		for _, value := range values[key] {
+			parts = append(parts, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *S3Storage) scope(now time.Time) string {
+	return now.Format(amzShortFormat) + "/" + s.Region + "/s3/aws4_request"
+}
+
+//signature returns the SigV4 signature for the canonical request.
+//headers must have lowercase names.
+func (s *S3Storage) signature(method string, u *url.URL, query url.Values, headers map[string]string, payloadHash string, now time.Time) (signature, signedHeaders string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders = strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		canonicalQuery(query),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		now.Format(amzDateFormat),
+		s.scope(now),
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretKey), now.Format(amzShortFormat))
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	return hex.EncodeToString(hmacSHA256(key, stringToSign)), signedHeaders
+}
+
+//do makes a signed request for the object with the given name
+func (s *S3Storage) do(method, name string, body io.ReadSeeker, payloadHash string, length int64) error {
+	now := time.Now().UTC()
+	u := s.objectURL(name)
+
+	headers := map[string]string{
+		"host":                 u.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           now.Format(amzDateFormat),
+	}
+	signature, signedHeaders := s.signature(method, u, url.Values{}, headers, payloadHash, now)
+
+	var reader io.Reader
+	if body != nil {
+		reader = body
+	}
+	req, err := http.NewRequest(method, u.String(), reader)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = length
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	req.Header.Set("X-Amz-Date", headers["x-amz-date"])
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKey, s.scope(now), signedHeaders, signature))
+
+	resp, err := s.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3: %s %s: %s %s", method, name, resp.Status, msg)
+	}
+	return nil
+}
+
+func (s *S3Storage) Put(name string, r io.ReadSeeker) error {
+	hash := sha256.New()
+	length, err := io.Copy(hash, r)
+	if err != nil {
+		return err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	return s.do("PUT", name, r, hex.EncodeToString(hash.Sum(nil)), length)
+}
+
+func (s *S3Storage) Delete(name string) error {
+	return s.do("DELETE", name, nil, sha256Hex(nil), 0)
+}
+
+func (s *S3Storage) URL(name string, expiry time.Duration) (string, error) {
+	return s.presign(name, expiry, time.Now().UTC()), nil
+}
+
+//presign returns a presigned GET URL for the object
+func (s *S3Storage) presign(name string, expiry time.Duration, now time.Time) string {
+	if expiry > maxPresign {
+		expiry = maxPresign
+	}
+
+	u := s.objectURL(name)
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", s.AccessKey+"/"+s.scope(now))
+	query.Set("X-Amz-Date", now.Format(amzDateFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expiry/time.Second)))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	signature, _ := s.signature("GET", u, query, map[string]string{"host": u.Host}, "UNSIGNED-PAYLOAD", now)
+	query.Set("X-Amz-Signature", signature)
+
+	u.RawQuery = canonicalQuery(query)
+	return u.String()
+}
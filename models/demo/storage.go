@@ -0,0 +1,153 @@
+// Copyright (C) 2015  TF2Stadium
+// Use of this source code is governed by the GPLv3
+// that can be found in the COPYING file.
+
+package demo
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/TF2Stadium/Helen/config"
+)
+
+//Storage stores demo files, and gives out links to download them
+type Storage interface {
+	Put(name string, r io.ReadSeeker) error
+	Delete(name string) error
+	//URL returns a link to download the file, which stops working after expiry
+	URL(name string, expiry time.Duration) (string, error)
+}
+
+var store Storage = NewLocalStorage(config.Constants.DemosFolder)
+
+//InitStorage sets up the storage backend for demos from the configuration
+func InitStorage() {
+	switch config.Constants.DemosStorage {
+	case "local", "":
+		store = NewLocalStorage(config.Constants.DemosFolder)
+	case "s3":
+		store = &S3Storage{
+			Endpoint:  config.Constants.S3Endpoint,
+			Region:    config.Constants.S3Region,
+			Bucket:    config.Constants.S3Bucket,
+			AccessKey: config.Constants.S3AccessKey,
+			SecretKey: config.Constants.S3SecretKey,
+			Secure:    config.Constants.S3Secure,
+			PathStyle: config.Constants.S3PathStyle,
+		}
+		logrus.Info("Storing demos in S3 bucket ", config.Constants.S3Bucket)
+	default:
+		logrus.Fatal("Unknown demo storage backend: ", config.Constants.DemosStorage)
+	}
+}
+
+//UseStorage sets the storage backend for demos
+func UseStorage(s Storage) {
+	store = s
+}
+
+//LocalStorage stores demos in a folder, and serves them over HTTP
+//with signed links
+type LocalStorage struct {
+	Folder string
+	secret []byte
+}
+
+func NewLocalStorage(folder string) *LocalStorage {
+	return &LocalStorage{
+		Folder: folder,
+		secret: []byte(config.Constants.CookieStoreSecret),
+	}
+}
+
+func (s *LocalStorage) path(name string) string {
+	return filepath.Join(s.Folder, filepath.Base(name))
+}
+
+func (s *LocalStorage) Put(name string, r io.ReadSeeker) error {
+	file, err := os.Create(s.path(name))
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(file, r); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+	return file.Close()
+}
+
+func (s *LocalStorage) Delete(name string) error {
+	err := os.Remove(s.path(name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *LocalStorage) signature(name string, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s\n%d", name, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *LocalStorage) URL(name string, expiry time.Duration) (string, error) {
+	expires := time.Now().Add(expiry).Unix()
+
+	values := url.Values{}
+	values.Set("expires", strconv.FormatInt(expires, 10))
+	values.Set("signature", s.signature(name, expires))
+
+	return fmt.Sprintf("%s/demos/%s?%s", config.Constants.PublicAddress, url.PathEscape(name), values.Encode()), nil
+}
+
+//valid returns true if the signature is correct and hasn't expired
+func (s *LocalStorage) valid(name string, values url.Values) bool {
+	expires, err := strconv.ParseInt(values.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return false
+	}
+
+	expected := s.signature(name, expires)
+	return hmac.Equal([]byte(expected), []byte(values.Get("signature")))
+}
+
+//ServeHTTP serves demos at /demos/<name>, if the link is signed and hasn't expired
+func (s *LocalStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/demos/")
+	if name == "" || strings.Contains(name, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	if !s.valid(name, r.URL.Query()) {
+		http.Error(w, "This link is invalid or has expired", http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	http.ServeFile(w, r, s.path(name))
+}
+
+//LocalHandler returns the handler for serving demos stored locally,
+//or nil if demos aren't stored locally
+func LocalHandler() http.Handler {
+	local, ok := store.(*LocalStorage)
+	if !ok {
+		return nil
+	}
+	return local
+}
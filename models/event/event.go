@@ -1,16 +1,14 @@
 package event
 
 import (
-	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/sirupsen/logrus"
-	"github.com/TF2Stadium/Helen/config"
 	"github.com/TF2Stadium/Helen/controllers/broadcaster"
-	"github.com/TF2Stadium/Helen/helpers"
 	"github.com/TF2Stadium/Helen/models/chat"
 	lobbypackage "github.com/TF2Stadium/Helen/models/lobby"
+	"github.com/TF2Stadium/Helen/models/lobby/timeline"
 	playerpackage "github.com/TF2Stadium/Helen/models/player"
 	"github.com/TF2Stadium/PlayerStatsScraper/steamid"
 	"github.com/TF2Stadium/TF2RconWrapper"
@@ -46,62 +44,47 @@ const (
 	ReservationOver string = "reservationOver"
 )
 
-var stop = make(chan struct{})
+var source Source
 
-func StartListening() {
-	q, err := helpers.AMQPChannel.QueueDeclare(config.Constants.RabbitMQQueue, false, false, false, false, nil)
-	if err != nil {
-		logrus.Fatal("Cannot declare queue ", err)
+//StartListening starts handling events delivered by src
+func StartListening(src Source) {
+	source = src
+	if err := source.Listen(Handle); err != nil {
+		logrus.Fatal("Cannot listen for events ", err)
 	}
-
-	msgs, err := helpers.AMQPChannel.Consume(q.Name, "", true, false, false, false, nil)
-	if err != nil {
-		logrus.Fatal("Cannot consume messages ", err)
-	}
-
-	go func() {
-		for {
-			select {
-			case msg := <-msgs:
-				var event Event
-
-				err := json.Unmarshal(msg.Body, &event)
-				if err != nil {
-					logrus.Fatal(err)
-				}
-				switch event.Name {
-				case PlayerDisconnected:
-					playerDisc(event.SteamID, event.LobbyID)
-				case PlayerSubstituted:
-					playerSub(event.SteamID, event.LobbyID, event.Self)
-				case PlayerConnected:
-					playerConn(event.SteamID, event.LobbyID)
-				case DisconnectedFromServer:
-					disconnectedFromServer(event.LobbyID)
-				case MatchEnded:
-					matchEnded(event.LobbyID, event.LogsID)
-				case ReservationOver:
-					reservationEnded(event.LobbyID)
-				case PlayerMumbleJoined:
-					mumbleJoined(uint(event.PlayerID))
-				case PlayerMumbleLeft:
-					mumbleLeft(uint(event.PlayerID))
-				case PlayersList:
-					playersList(event.Players)
-				}
-			case <-stop:
-				return
-			}
-		}
-	}()
 }
 
 func StopListening() {
-	stop <- struct{}{}
+	source.Close()
+}
+
+//Handle processes a single event
+func Handle(event Event) {
+	switch event.Name {
+	case PlayerDisconnected:
+		playerDisc(event.SteamID, event.LobbyID)
+	case PlayerSubstituted:
+		playerSub(event.SteamID, event.LobbyID, event.Self)
+	case PlayerConnected:
+		playerConn(event.SteamID, event.LobbyID)
+	case DisconnectedFromServer:
+		disconnectedFromServer(event.LobbyID)
+	case MatchEnded:
+		matchEnded(event.LobbyID, event.LogsID)
+	case ReservationOver:
+		reservationEnded(event.LobbyID)
+	case PlayerMumbleJoined:
+		mumbleJoined(uint(event.PlayerID))
+	case PlayerMumbleLeft:
+		mumbleLeft(uint(event.PlayerID))
+	case PlayersList:
+		playersList(event.Players)
+	}
 }
 
 func reservationEnded(lobbyID uint) {
 	lobby, _ := lobbypackage.GetLobbyByID(lobbyID)
+	timeline.Log(lobby.ID, timeline.Server, nil, "serveme.tf reservation ended")
 	lobby.Close(false, false)
 	chat.SendNotification("Lobby Closed (serveme.tf reservation ended)", int(lobby.ID))
 }
@@ -117,6 +100,7 @@ func playerDisc(steamID string, lobbyID uint) {
 	lobby.AfterPlayerNotInGameFunc(player, 5*time.Minute, func() {
 		lobby.Substitute(player)
 		player.NewReport(playerpackage.Substitute, lobby.ID)
+		timeline.Log(lobby.ID, timeline.Report, player, "Didn't join the game in 5 minutes")
 		chat.SendNotification(fmt.Sprintf("%s has been reported for not joining the game in 5 minutes", player.Alias()), int(lobby.ID))
 	})
 }
@@ -140,10 +124,11 @@ func playerSub(steamID string, lobbyID uint, self bool) {
 	lobby.Substitute(player)
 	if self {
 		player.NewReport(playerpackage.Substitute, lobby.ID)
-
+		timeline.Log(lobby.ID, timeline.Report, player, "!sub")
 	} else {
 		// ban player from joining lobbies for 30 minutes
 		player.NewReport(playerpackage.Vote, lobby.ID)
+		timeline.Log(lobby.ID, timeline.Report, player, "!rep")
 	}
 
 	chat.SendNotification(fmt.Sprintf("%s has been reported.", player.Alias()), int(lobby.ID))
@@ -165,6 +150,7 @@ func disconnectedFromServer(lobbyID uint) {
 		return
 	}
 
+	timeline.Log(lobby.ID, timeline.Server, nil, "Connection to server lost")
 	lobby.Close(false, false)
 	chat.SendNotification("Lobby Closed (Connection to server lost)", int(lobby.ID))
 }
@@ -175,7 +161,7 @@ func matchEnded(lobbyID uint, logsID int) {
 		logrus.Error(err)
 		return
 	}
-	lobby.Close(false, true)
+	lobby.CloseMatchEnded(logsID)
 
 	logs := fmt.Sprintf("http://logs.tf/%d", logsID)
 	msg := fmt.Sprintf("Lobby Ended. Logs: %s", logs)
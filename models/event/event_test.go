@@ -0,0 +1,46 @@
+package event_test
+
+import (
+	"testing"
+
+	"github.com/TF2Stadium/Helen/internal/testhelpers"
+	. "github.com/TF2Stadium/Helen/models/event"
+	"github.com/TF2Stadium/Helen/models/lobby"
+	"github.com/TF2Stadium/Helen/models/player"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	testhelpers.CleanupDB()
+}
+
+func TestLobbyLifecycle(t *testing.T) {
+	memory, source := testhelpers.StartInProcess()
+	defer StopListening()
+
+	lob := testhelpers.CreateLobby()
+	require.NoError(t, lob.SetupServer())
+	lob.SetState(lobby.Waiting)
+	assert.Len(t, memory.CallsTo("Pauling.SetupServer"), 1)
+	assert.Len(t, memory.CallsTo("Fumble.CreateLobby"), 1)
+
+	var players []*player.Player
+	for i := 0; i < lob.RequiredPlayers(); i++ {
+		p := testhelpers.CreatePlayer()
+		require.NoError(t, lob.AddPlayer(p, i, ""))
+		players = append(players, p)
+	}
+	lob.Start()
+
+	testhelpers.InjectEvent(source, PlayerConnected, lob.ID, players[0].SteamID)
+	assert.True(t, lob.IsPlayerInGame(players[0]))
+
+	testhelpers.InjectEvent(source, PlayerDisconnected, lob.ID, players[0].SteamID)
+	assert.False(t, lob.IsPlayerInGame(players[0]))
+
+	testhelpers.InjectEvent(source, DisconnectedFromServer, lob.ID, "")
+	assert.Equal(t, lobby.Ended, lob.CurrentState())
+	assert.Len(t, memory.CallsTo("EndLobby"), 1)
+	assert.Len(t, source.Events(), 3)
+}
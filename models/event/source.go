@@ -0,0 +1,119 @@
+package event
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/streadway/amqp"
+)
+
+//Source delivers events from Pauling, Fumble and the Twitch bot to Helen
+type Source interface {
+	//Listen starts delivering events to handle. It shouldn't block.
+	Listen(handle func(Event)) error
+	//Close stops delivering events
+	Close()
+}
+
+//AMQPSource reads events from a RabbitMQ queue
+type AMQPSource struct {
+	channel *amqp.Channel
+	queue   string
+	stop    chan struct{}
+}
+
+//NewAMQPSource returns a source which consumes events sent over queue
+func NewAMQPSource(channel *amqp.Channel, queue string) *AMQPSource {
+	return &AMQPSource{
+		channel: channel,
+		queue:   queue,
+		stop:    make(chan struct{}),
+	}
+}
+
+func (s *AMQPSource) Listen(handle func(Event)) error {
+	q, err := s.channel.QueueDeclare(s.queue, false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+
+	msgs, err := s.channel.Consume(q.Name, "", true, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			select {
+			case msg := <-msgs:
+				var event Event
+
+				err := json.Unmarshal(msg.Body, &event)
+				if err != nil {
+					logrus.Fatal(err)
+				}
+				handle(event)
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (s *AMQPSource) Close() {
+	s.stop <- struct{}{}
+}
+
+//MemorySource is an in-process event source. Events are injected
+//with Inject, and are handled before Inject returns.
+type MemorySource struct {
+	mu     sync.Mutex
+	handle func(Event)
+	events []Event
+}
+
+//NewMemorySource returns a new in-process event source
+func NewMemorySource() *MemorySource {
+	return &MemorySource{}
+}
+
+func (s *MemorySource) Listen(handle func(Event)) error {
+	s.mu.Lock()
+	s.handle = handle
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemorySource) Close() {
+	s.mu.Lock()
+	s.handle = nil
+	s.mu.Unlock()
+}
+
+//Inject delivers e as if it was sent by Pauling/Fumble. Events injected
+//while the source isn't being listened to are dropped.
+func (s *MemorySource) Inject(e Event) {
+	s.mu.Lock()
+	handle := s.handle
+	if handle != nil {
+		s.events = append(s.events, e)
+	}
+	s.mu.Unlock()
+
+	if handle != nil {
+		handle(e)
+	}
+}
+
+//Events returns all the events delivered so far
+func (s *MemorySource) Events() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := make([]Event, len(s.events))
+	copy(events, s.events)
+	return events
+}
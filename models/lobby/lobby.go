@@ -19,8 +19,11 @@ import (
 	db "github.com/TF2Stadium/Helen/database"
 	"github.com/TF2Stadium/Helen/helpers"
 	"github.com/TF2Stadium/Helen/models/chat"
+	"github.com/TF2Stadium/Helen/models/demo"
 	"github.com/TF2Stadium/Helen/models/gameserver"
 	"github.com/TF2Stadium/Helen/models/lobby/format"
+	"github.com/TF2Stadium/Helen/models/lobby/timeline"
+	"github.com/TF2Stadium/Helen/models/notification"
 	"github.com/TF2Stadium/Helen/models/player"
 	"github.com/TF2Stadium/Helen/models/rpc"
 	"github.com/TF2Stadium/PlayerStatsScraper/steamid"
@@ -119,10 +122,12 @@ type Lobby struct {
 	Slots []LobbySlot `gorm:"ForeignKey:LobbyID"` // List of occupied slots
 
 	RegionLock        bool
+	RegionLockPing    int               // if not 0, region lock uses the ping players report to the server (in ms) instead of their region
 	PlayerWhitelist   string            // URL of steam group
 	TwitchChannel     string            // twitch channel, slots will be restricted
 	TwitchRestriction TwitchRestriction // restricted to either followers or subs
 	ServemeID         int               // if serveme was used to get this server, stores the server ID
+	ServemeExtension  time.Duration     // total time the serveme reservation has been extended by
 
 	// Team name aliases
 	RedTeamName string
@@ -218,12 +223,10 @@ func (lobby *Lobby) Delete() {
 
 	if lobby.ServemeID != 0 {
 		context := helpers.GetServemeContext(lobby.ServerInfo.Host)
-		err := context.Delete(lobby.ServemeID, lobby.CreatedBySteamID)
-		for err != nil {
-			err = context.Delete(lobby.ServemeID, lobby.CreatedBySteamID)
-		}
+		DeleteReservation(context, lobby.ServemeID, lobby.CreatedBySteamID)
 	}
 
+	db.DB.Where("lobby_id = ?", lobby.ID).Delete(&Requirement{})
 	db.DB.Delete(lobby)
 	db.DB.Delete(&lobby.ServerInfo)
 
@@ -251,9 +254,12 @@ func (l *Lobby) SetState(s State) {
 }
 
 //ServemeCheck checks the status of the serveme reservation for the lobby
-//(if any) every 10 seconds in a goroutine, and closes the lobby if it has ended
+//(if any) every 10 seconds in a goroutine, and closes the lobby if it has ended.
+//The reservation is extended if the lobby is still in progress when it's about to end.
 func (l *Lobby) ServemeCheck(context *servemetf.Context) {
 	go func() {
+		warned := false
+
 		for {
 			ended, err := context.Ended(l.ServemeID, l.CreatedBySteamID)
 			if err != nil {
@@ -266,11 +272,61 @@ func (l *Lobby) ServemeCheck(context *servemetf.Context) {
 				}
 				return
 			}
+
+			if l.CurrentState() == InProgress {
+				err := l.extendReservation(context)
+				if err == errMaxExtension && !warned {
+					chat.SendNotification("The Serveme reservation can't be extended any further, the lobby will close when it ends.", int(l.ID))
+					warned = true
+				} else if err != nil && err != errMaxExtension {
+					logrus.Error(err)
+				}
+			}
 			time.Sleep(10 * time.Second)
 		}
 	}()
 }
 
+var errMaxExtension = errors.New("serveme reservation has been extended by the maximum time")
+
+//extendReservation extends the lobby's serveme reservation if it ends
+//in less than config.Constants.ServemeExtendAt
+func (l *Lobby) extendReservation(context *servemetf.Context) error {
+	reservation, err := helpers.GetServemeReservation(context, l.ServemeID, l.CreatedBySteamID)
+	if err != nil {
+		return err
+	}
+
+	endsAt, err := time.Parse(servemetf.TimeFormat, reservation.EndsAt)
+	if err != nil {
+		return err
+	}
+	if time.Until(endsAt) > config.Constants.ServemeExtendAt {
+		return nil
+	}
+	if l.ServemeExtension >= GetMaxExtension() {
+		return errMaxExtension
+	}
+
+	reservation, err = helpers.ExtendServemeReservation(context, l.ServemeID, l.CreatedBySteamID)
+	if err != nil {
+		return err
+	}
+	newEndsAt, err := time.Parse(servemetf.TimeFormat, reservation.EndsAt)
+	if err != nil {
+		return err
+	}
+	if !newEndsAt.After(endsAt) {
+		return errMaxExtension // serveme.tf didn't extend it, probably because the server is reserved after it
+	}
+
+	l.ServemeExtension += newEndsAt.Sub(endsAt)
+	db.DB.Model(&Lobby{}).Where("id = ?", l.ID).UpdateColumn("serveme_extension", l.ServemeExtension)
+
+	chat.SendNotification(fmt.Sprintf("Serveme reservation extended till %s", newEndsAt.Format("15:04 MST")), int(l.ID))
+	return nil
+}
+
 func RestoreServemeChecks() {
 	var ids []uint
 	db.DB.Model(&Lobby{}).Where("state <> ? AND serveme_id <> 0", Ended).Pluck("id", &ids)
@@ -278,6 +334,11 @@ func RestoreServemeChecks() {
 	for _, id := range ids {
 		lobby, _ := GetLobbyByIDServer(id)
 		context := helpers.GetServemeContext(lobby.ServerInfo.Host)
+		if lobby.State == Initializing {
+			// Helen was restarted while the reservation was starting
+			lobby.AwaitReservation(context)
+			continue
+		}
 		lobby.ServemeCheck(context)
 	}
 }
@@ -507,6 +568,16 @@ func (lobby *Lobby) AddPlayer(p *player.Player, slot int, password string) error
 	lobby.Lock()
 	db.DB.Create(newSlotObj)
 	lobby.Unlock()
+
+	class, team, _ := format.GetSlotTeamClass(lobby.Type, slot)
+	switch {
+	case slotChange:
+		timeline.Log(lobby.ID, timeline.SlotChange, p, team+" "+class)
+	case isSubstitution:
+		timeline.Log(lobby.ID, timeline.Join, p, team+" "+class+" (substitute)")
+	default:
+		timeline.Log(lobby.ID, timeline.Join, p, team+" "+class)
+	}
 	if !slotChange {
 		if p.TwitchName != "" {
 			rpc.TwitchBotAnnouce(p.TwitchName, lobby.ID)
@@ -529,6 +600,7 @@ func (lobby *Lobby) RemovePlayer(player *player.Player) error {
 		return err
 	}
 
+	timeline.Log(lobby.ID, timeline.Leave, player, "")
 	rpc.DisallowPlayer(lobby.ID, player.SteamID, player.ID)
 	lobby.OnChange(true)
 	return nil
@@ -546,6 +618,7 @@ func (lobby *Lobby) ReadyPlayer(player *player.Player) error {
 	if err != nil {
 		return errors.New("Player is not in the lobby.")
 	}
+	timeline.Log(lobby.ID, timeline.Ready, player, "")
 	lobby.OnChange(false)
 	return nil
 }
@@ -556,6 +629,7 @@ func (lobby *Lobby) UnreadyPlayer(player *player.Player) error {
 	if err != nil {
 		return errors.New("Player is not in the lobby.")
 	}
+	timeline.Log(lobby.ID, timeline.Unready, player, "")
 
 	lobby.OnChange(false)
 	return nil
@@ -814,6 +888,20 @@ func (lobby *Lobby) SetupServer() error {
 //If rpc == true, the log listener in Pauling for the corresponding server is stopped, this is
 //used when the lobby is closed manually by a player
 func (lobby *Lobby) Close(doRPC, matchEnded bool) {
+	detail := ""
+	if matchEnded {
+		detail = "Match ended"
+	}
+	lobby.close(doRPC, matchEnded, detail)
+}
+
+//CloseMatchEnded closes the lobby after its match ended, logsID is the
+//match's logs.tf ID, shown in the timeline
+func (lobby *Lobby) CloseMatchEnded(logsID int) {
+	lobby.close(false, true, fmt.Sprintf("Match ended (logs.tf #%d)", logsID))
+}
+
+func (lobby *Lobby) close(doRPC, matchEnded bool, detail string) {
 	var count int
 
 	db.DB.Preload("ServerInfo").First(lobby, lobby.ID)
@@ -824,6 +912,7 @@ func (lobby *Lobby) Close(doRPC, matchEnded bool) {
 
 	lobby.SetState(Ended)
 	db.DB.First(lobby).UpdateColumn("match_ended", matchEnded)
+	timeline.Log(lobby.ID, timeline.Close, nil, detail)
 	//db.DB.Exec("DELETE FROM spectators_players_lobbies WHERE lobby_id = ?", lobby.ID)
 	if doRPC {
 		rpc.End(lobby.ID)
@@ -864,10 +953,21 @@ func (lobby *Lobby) DownloadDemo(context *servemetf.Context) {
 	err := context.DownloadDemo(lobby.ServemeID, lobby.CreatedBySteamID, file)
 	if err != nil {
 		logrus.Error(err)
-	} else {
-		url := fmt.Sprintf("%s/demos/%d.dem", config.Constants.PublicAddress, lobby.ID)
-		chat.SendNotification("STV Demo for this lobby is available at "+url, int(lobby.ID))
+		return
 	}
+
+	var playerIDs []uint
+	for _, slot := range lobby.GetAllSlots() {
+		playerIDs = append(playerIDs, slot.PlayerID)
+	}
+
+	archived, err := demo.Archive(lobby.ID, lobby.MapName, file, playerIDs)
+	if err != nil {
+		logrus.Error(err)
+		return
+	}
+
+	chat.SendNotification("STV Demo for this lobby is available at "+archived.URL, int(lobby.ID))
 }
 
 //UpdateStats updates the PlayerStats records for all players in the lobby
@@ -886,6 +986,8 @@ func (lobby *Lobby) UpdateStats() {
 	lobby.OnChange(false)
 }
 
+//UpdateHours saves the lobby's result from its logs.tf log, and adds the
+//time played on each class and wins/losses to players' stats
 func (lobby *Lobby) UpdateHours(logsID int) error {
 	db.DB.Model(&Lobby{}).Where("id = ?", lobby.ID).UpdateColumn("logstf_id", logsID)
 
@@ -894,6 +996,13 @@ func (lobby *Lobby) UpdateHours(logsID int) error {
 		return err
 	}
 
+	result, results := parseResult(logs, time.Now())
+	result.LobbyID = lobby.ID
+	// the lobby's result can only be saved once, so stats aren't counted twice
+	if err := db.DB.Create(result).Error; err != nil {
+		return err
+	}
+
 	for steamID, playerStats := range logs.Players {
 		commid, _ := steamid.SteamIdToCommId(steamID)
 		player, err := player.GetPlayerWithStats(commid)
@@ -902,6 +1011,18 @@ func (lobby *Lobby) UpdateHours(logsID int) error {
 			continue
 		}
 
+		playerResult := results[steamID]
+		playerResult.LobbyID = lobby.ID
+		playerResult.PlayerID = player.ID
+		playerResult.SteamID = player.SteamID
+		db.DB.Create(playerResult)
+
+		if playerResult.Won {
+			player.Stats.Wins++
+		} else if result.Winner != "" {
+			player.Stats.Losses++
+		}
+
 		for _, class := range playerStats.ClassStats {
 			totalTime := time.Second * time.Duration(class.TotalTime)
 
@@ -952,11 +1073,13 @@ func (lobby *Lobby) SetInGame(player *player.Player) error {
 	}
 	inGameMu.Unlock()
 
+	timeline.Log(lobby.ID, timeline.Connect, player, "")
 	return lobby.setInGameStatus(player, true)
 }
 
 //SetNotInGame sets the in-game status of the given player to false
 func (lobby *Lobby) SetNotInGame(player *player.Player) error {
+	timeline.Log(lobby.ID, timeline.Disconnect, player, "")
 	return lobby.setInGameStatus(player, false)
 }
 
@@ -981,6 +1104,7 @@ func (lobby *Lobby) SetNotInMumble(player *player.Player) error {
 func (lobby *Lobby) Start() {
 	rows := db.DB.Model(&Lobby{}).Where("id = ? AND state <> ?", lobby.ID, InProgress).Update("state", InProgress).RowsAffected
 	if rows != 0 { // if == 0, then game is already in progress
+		timeline.Log(lobby.ID, timeline.Start, nil, "")
 		go rpc.ReExecConfig(lobby.ID, false)
 
 		// var playerids []uint
@@ -1041,6 +1165,12 @@ func (lobby *Lobby) Substitute(player *player.Player) {
 	lobby.Lock()
 	db.DB.Model(&LobbySlot{}).Where("lobby_id = ? AND player_id = ?", lobby.ID, player.ID).UpdateColumn("needs_sub", true)
 	lobby.Unlock()
+	timeline.Log(lobby.ID, timeline.Sub, player, "")
+	notification.Send(player.ID, player.SteamID, notification.SubRequest,
+		fmt.Sprintf("A substitute has been requested for your slot in Lobby #%d", lobby.ID),
+		struct {
+			LobbyID uint `json:"lobbyID"`
+		}{lobby.ID})
 
 	var count int
 	db.DB.Model(&LobbySlot{}).Where("lobby_id = ? AND needs_sub = TRUE", lobby.ID).Count(&count)
@@ -50,8 +50,9 @@ type LobbyData struct {
 	TwitchChannel     string `json:"twitchChannel"`
 	TwitchRestriction string `json:"twitchRestriction"`
 
-	RegionLock bool   `json:"regionLock"`
-	SteamGroup string `json:"steamGroup"`
+	RegionLock     bool   `json:"regionLock"`
+	RegionLockPing int    `json:"regionLockPing"`
+	SteamGroup     string `json:"steamGroup"`
 
 	RedTeamName string `json:"redTeamName"`
 	BluTeamName string `json:"bluTeamName"`
@@ -69,6 +70,7 @@ type LobbyData struct {
 	WhitelistID string        `json:"whitelistId"`
 
 	Spectators []SpecDetails `json:"spectators,omitempty"`
+	Result     *MatchResult  `json:"result,omitempty"` // only for ended lobbies with logs
 }
 
 type LobbyListData struct {
@@ -173,6 +175,7 @@ func DecorateLobbyData(lobby *Lobby, playerInfo bool) LobbyData {
 		TwitchChannel:     lobby.TwitchChannel,
 		TwitchRestriction: lobby.TwitchRestriction.String(),
 		RegionLock:        lobby.RegionLock,
+		RegionLockPing:    lobby.RegionLockPing,
 		RedTeamName:       lobby.RedTeamName,
 		BluTeamName:       lobby.BluTeamName,
 
@@ -230,6 +233,9 @@ func DecorateLobbyData(lobby *Lobby, playerInfo bool) LobbyData {
 	}
 
 	lobbyData.Spectators = spectators
+	if lobby.MatchEnded {
+		lobbyData.Result = GetMatchResult(lobby.ID)
+	}
 
 	return lobbyData
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/TF2Stadium/Helen/models/gameserver"
 	. "github.com/TF2Stadium/Helen/models/lobby"
 	"github.com/TF2Stadium/Helen/models/lobby/format"
+	"github.com/TF2Stadium/Helen/models/lobby/timeline"
 	. "github.com/TF2Stadium/Helen/models/player"
 	"github.com/TF2Stadium/PlayerStatsScraper/steamid"
 	"github.com/TF2Stadium/logstf"
@@ -578,5 +579,29 @@ func TestUpdateHours(t *testing.T) {
 	require.NoError(t, err)
 	lobby, _ = GetLobbyByID(lobby.ID)
 	assert.Equal(t, logsID, lobby.LogstfID)
+
+	result := GetMatchResult(lobby.ID)
+	require.NotNil(t, result)
+	assert.Len(t, result.Players, len(players))
 	//TODO: check player.Stats for updated hours
 }
+
+func TestLobbyTimeline(t *testing.T) {
+	t.Parallel()
+	lobby := testhelpers.CreateLobby()
+	lobby.Save()
+
+	player := testhelpers.CreatePlayer()
+	require.NoError(t, lobby.AddPlayer(player, 0, ""))
+	require.NoError(t, lobby.AddPlayer(player, 1, ""))
+	require.NoError(t, lobby.RemovePlayer(player))
+	lobby.Close(false, true)
+
+	events := timeline.Get(lobby.ID)
+	require.Len(t, events, 4)
+	assert.Equal(t, timeline.Join, events[0].Kind)
+	assert.Equal(t, player.SteamID, events[0].SteamID)
+	assert.Equal(t, timeline.SlotChange, events[1].Kind)
+	assert.Equal(t, timeline.Leave, events[2].Kind)
+	assert.Equal(t, timeline.Close, events[3].Kind)
+}
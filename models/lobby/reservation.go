@@ -0,0 +1,137 @@
+// Copyright (C) 2015  TF2Stadium
+// Use of this source code is governed by the GPLv3
+// that can be found in the COPYING file.
+
+package lobby
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/TF2Stadium/Helen/controllers/broadcaster"
+	"github.com/TF2Stadium/servemetf"
+)
+
+type ReservationStatus string
+
+const (
+	ReservationWaiting     ReservationStatus = "waiting"     // waiting for serveme.tf to start the server
+	ReservationConfiguring ReservationStatus = "configuring" // server is up, Pauling is setting it up
+	ReservationReady       ReservationStatus = "ready"       // lobby is open for players
+	ReservationFailed      ReservationStatus = "failed"      // lobby has been deleted
+)
+
+var (
+	reservationsMu = new(sync.RWMutex)
+	reservations   = make(map[uint]ReservationStatus) // lobby id -> status of it's reservation
+
+	reservationTimeout      = 3 * time.Minute
+	reservationPollInterval = 10 * time.Second
+
+	deleteReservationAttempts = 5
+	deleteReservationBackoff  = 2 * time.Second
+)
+
+type reservationProgress struct {
+	LobbyID uint              `json:"id"`
+	Status  ReservationStatus `json:"status"`
+	Message string            `json:"message,omitempty"`
+}
+
+//GetReservationStatus returns the status of the serveme reservation
+//for the given lobby, ok is false if it isn't being tracked.
+func GetReservationStatus(lobbyID uint) (status ReservationStatus, ok bool) {
+	reservationsMu.RLock()
+	defer reservationsMu.RUnlock()
+	status, ok = reservations[lobbyID]
+	return
+}
+
+func (lobby *Lobby) setReservationStatus(status ReservationStatus, message string) {
+	reservationsMu.Lock()
+	if status == ReservationReady || status == ReservationFailed {
+		delete(reservations, lobby.ID)
+	} else {
+		reservations[lobby.ID] = status
+	}
+	reservationsMu.Unlock()
+
+	broadcaster.SendMessage(lobby.CreatedBySteamID, "lobbyReservationStatus",
+		reservationProgress{lobby.ID, status, message})
+}
+
+//DeleteReservation deletes the given serveme reservation. Failed deletes are retried
+//a few times before giving up.
+func DeleteReservation(context *servemetf.Context, id int, steamID string) error {
+	var err error
+
+	for i := 0; i < deleteReservationAttempts; i++ {
+		if i != 0 {
+			time.Sleep(time.Duration(i) * deleteReservationBackoff)
+		}
+
+		err = context.Delete(id, steamID)
+		if err == nil {
+			return nil
+		}
+	}
+
+	logrus.Errorf("Couldn't delete serveme reservation #%d: %v", id, err)
+	return err
+}
+
+//AwaitReservation waits in the background for the lobby's serveme reservation to start,
+//then sets up the game server and opens the lobby. The lobby creator gets a
+//lobbyReservationStatus event on every change. If the reservation doesn't start in time,
+//or the server can't be setup, the lobby (and the reservation) is deleted.
+func (lobby *Lobby) AwaitReservation(context *servemetf.Context) {
+	lobby.setReservationStatus(ReservationWaiting, "")
+
+	go func() {
+		start := time.Now()
+
+		for {
+			if lobby.CurrentState() != Initializing {
+				// lobby was closed while waiting, Close deletes the reservation
+				reservationsMu.Lock()
+				delete(reservations, lobby.ID)
+				reservationsMu.Unlock()
+				return
+			}
+
+			status, err := context.Status(lobby.ServemeID, lobby.CreatedBySteamID)
+			if err != nil {
+				logrus.Error(err)
+			}
+			if status == "ready" {
+				break
+			}
+
+			if time.Since(start) >= reservationTimeout {
+				lobby.Delete()
+				lobby.setReservationStatus(ReservationFailed, "Couldn't get Serveme reservation, try another server.")
+				return
+			}
+			time.Sleep(reservationPollInterval)
+		}
+
+		if lobby.CurrentState() != Initializing {
+			// lobby was closed while waiting
+			return
+		}
+
+		lobby.setReservationStatus(ReservationConfiguring, "")
+		lobby.ServemeCheck(context)
+
+		if err := lobby.SetupServer(); err != nil {
+			lobby.Delete()
+			lobby.setReservationStatus(ReservationFailed, err.Error())
+			return
+		}
+
+		lobby.SetState(Waiting)
+		lobby.setReservationStatus(ReservationReady, "")
+		BroadcastLobbyList()
+	}()
+}
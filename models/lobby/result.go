@@ -0,0 +1,124 @@
+// Copyright (C) 2015  TF2Stadium
+// Use of this source code is governed by the GPLv3
+// that can be found in the COPYING file.
+
+package lobby
+
+import (
+	"strings"
+	"time"
+
+	db "github.com/TF2Stadium/Helen/database"
+	"github.com/TF2Stadium/logstf"
+)
+
+//MatchResult is the final result of a lobby whose match ended, taken from logs.tf
+type MatchResult struct {
+	ID      uint `json:"-"`
+	LobbyID uint `json:"-" sql:"not null;unique"`
+
+	RedScore  int       `json:"redScore"`
+	BluScore  int       `json:"bluScore"`
+	Winner    string    `json:"winner"` // "red", "blu", or empty if the match was a tie
+	StartedAt time.Time `json:"startedAt"`
+	EndedAt   time.Time `json:"endedAt"`
+
+	Players []*PlayerResult `json:"players" sql:"-"`
+}
+
+//PlayerResult is a player's stat line in a finished lobby
+type PlayerResult struct {
+	ID       uint   `json:"-"`
+	LobbyID  uint   `json:"-" sql:"index"`
+	PlayerID uint   `json:"-" sql:"index"`
+	SteamID  string `json:"steamid"`
+
+	Team    string `json:"team"`
+	Class   string `json:"class"` // class the player played the most
+	Won     bool   `json:"won"`
+	Kills   int    `json:"kills"`
+	Deaths  int    `json:"deaths"`
+	Assists int    `json:"assists"`
+	Damage  int    `json:"damage"`
+	Heals   int    `json:"heals"`
+}
+
+//logs.tf uses different names for teams and classes
+var (
+	logsTeams = map[string]string{
+		"Red":  "red",
+		"Blue": "blu",
+	}
+	logsClasses = map[string]string{
+		"demoman":      "demoman",
+		"engineer":     "engineer",
+		"heavyweapons": "heavy",
+		"medic":        "medic",
+		"pyro":         "pyro",
+		"scout":        "scout",
+		"sniper":       "sniper",
+		"soldier":      "soldier",
+		"spy":          "spy",
+	}
+)
+
+//parseResult makes a MatchResult out of logs, for a match that ended at endedAt.
+//Player stat lines are keyed by their SteamID in logs (SteamID3),
+//and don't have PlayerID or SteamID set.
+func parseResult(logs *logstf.Logs, endedAt time.Time) (*MatchResult, map[string]*PlayerResult) {
+	result := &MatchResult{
+		RedScore:  logs.Info.Red.Score,
+		BluScore:  logs.Info.Blue.Score,
+		StartedAt: endedAt.Add(-time.Duration(logs.Info.TotalLength) * time.Second),
+		EndedAt:   endedAt,
+	}
+
+	switch {
+	case result.RedScore > result.BluScore:
+		result.Winner = "red"
+	case result.BluScore > result.RedScore:
+		result.Winner = "blu"
+	}
+
+	players := make(map[string]*PlayerResult)
+	for steamID, stats := range logs.Players {
+		team := logsTeams[stats.Team]
+		if team == "" {
+			team = strings.ToLower(stats.Team)
+		}
+
+		var class string
+		var classTime int
+		for _, classStats := range stats.ClassStats {
+			if classStats.TotalTime > classTime {
+				class = logsClasses[classStats.Type]
+				classTime = classStats.TotalTime
+			}
+		}
+
+		players[steamID] = &PlayerResult{
+			Team:    team,
+			Class:   class,
+			Won:     result.Winner != "" && team == result.Winner,
+			Kills:   stats.Kills,
+			Deaths:  stats.Deaths,
+			Assists: stats.Assists,
+			Damage:  stats.Damage,
+			Heals:   stats.Heal,
+		}
+	}
+
+	return result, players
+}
+
+//GetMatchResult returns the result of the given lobby, with every player's
+//stat line. Returns nil if the lobby doesn't have one.
+func GetMatchResult(lobbyID uint) *MatchResult {
+	result := &MatchResult{}
+	if db.DB.Where("lobby_id = ?", lobbyID).First(result).RecordNotFound() {
+		return nil
+	}
+
+	db.DB.Where("lobby_id = ?", lobbyID).Order("team, id").Find(&result.Players)
+	return result
+}
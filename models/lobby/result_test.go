@@ -0,0 +1,68 @@
+package lobby
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TF2Stadium/logstf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseResult(t *testing.T) {
+	logs := &logstf.Logs{
+		Players: map[string]logstf.PlayerStats{
+			"[U:1:1]": {
+				Team: "Red", Kills: 20, Deaths: 5, Damage: 8000,
+				ClassStats: []logstf.ClassStats{
+					{Type: "scout", TotalTime: 100},
+					{Type: "heavyweapons", TotalTime: 1500},
+				},
+			},
+			"[U:1:2]": {
+				Team: "Blue", Deaths: 10, Heal: 15000,
+				ClassStats: []logstf.ClassStats{{Type: "medic", TotalTime: 1800}},
+			},
+		},
+	}
+	logs.Info.Red.Score = 5
+	logs.Info.Blue.Score = 2
+	logs.Info.TotalLength = 1800
+
+	ended := time.Date(2016, 1, 1, 20, 0, 0, 0, time.UTC)
+	result, players := parseResult(logs, ended)
+
+	assert.Equal(t, "red", result.Winner)
+	assert.Equal(t, 5, result.RedScore)
+	assert.Equal(t, 2, result.BluScore)
+	assert.Equal(t, ended.Add(-30*time.Minute), result.StartedAt)
+	assert.Equal(t, ended, result.EndedAt)
+
+	require.Len(t, players, 2)
+	red := players["[U:1:1]"]
+	assert.Equal(t, "red", red.Team)
+	assert.Equal(t, "heavy", red.Class)
+	assert.True(t, red.Won)
+	assert.Equal(t, 20, red.Kills)
+	assert.Equal(t, 8000, red.Damage)
+
+	blu := players["[U:1:2]"]
+	assert.Equal(t, "blu", blu.Team)
+	assert.Equal(t, "medic", blu.Class)
+	assert.False(t, blu.Won)
+	assert.Equal(t, 15000, blu.Heals)
+}
+
+func TestParseResultTie(t *testing.T) {
+	logs := &logstf.Logs{
+		Players: map[string]logstf.PlayerStats{
+			"[U:1:1]": {Team: "Red"},
+		},
+	}
+	logs.Info.Red.Score = 3
+	logs.Info.Blue.Score = 3
+
+	result, players := parseResult(logs, time.Now())
+	assert.Equal(t, "", result.Winner)
+	assert.False(t, players["[U:1:1]"].Won)
+}
@@ -0,0 +1,43 @@
+package lobby
+
+import (
+	"errors"
+	"time"
+
+	"github.com/TF2Stadium/Helen/config"
+	db "github.com/TF2Stadium/Helen/database"
+)
+
+//ServemeSettings are the serveme.tf settings admins can change, stored in a single row
+type ServemeSettings struct {
+	ID           uint
+	MaxExtension int // minutes, see GetMaxExtension
+}
+
+func (ServemeSettings) TableName() string { return "serveme_settings" }
+
+var ErrMaxExtension = errors.New("The maximum extension can't be negative")
+
+//GetMaxExtension returns the maximum total time a lobby's serveme reservation
+//can be extended by, 0 if extending is disabled. SERVEME_MAX_EXTENSION is
+//used till an admin sets it.
+func GetMaxExtension() time.Duration {
+	settings := &ServemeSettings{}
+	if err := db.DB.First(settings).Error; err != nil {
+		return config.Constants.ServemeMaxExtension
+	}
+	return time.Duration(settings.MaxExtension) * time.Minute
+}
+
+//SetMaxExtension sets the maximum total time a lobby's serveme reservation can
+//be extended by, rounded down to the minute. 0 disables extending.
+func SetMaxExtension(max time.Duration) error {
+	if max < 0 {
+		return ErrMaxExtension
+	}
+
+	settings := &ServemeSettings{}
+	db.DB.FirstOrInit(settings)
+	settings.MaxExtension = int(max / time.Minute)
+	return db.DB.Save(settings).Error
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2015  TF2Stadium
+// Use of this source code is governed by the GPLv3
+// that can be found in the COPYING file.
+
+//Package timeline keeps a persistent log of everything that happened in a lobby
+package timeline
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	db "github.com/TF2Stadium/Helen/database"
+	"github.com/TF2Stadium/Helen/models/player"
+)
+
+type Kind string
+
+const (
+	Join        Kind = "join"        // player joined a slot
+	SlotChange  Kind = "slotChange"  // player moved to another slot
+	Leave       Kind = "leave"       // player left their slot
+	Kick        Kind = "kick"        // player was kicked by the lobby leader or a mod
+	Ban         Kind = "ban"         // player was banned from the lobby
+	Sub         Kind = "sub"         // player's slot needs a substitute
+	Report      Kind = "report"      // player was reported in-game
+	Ready       Kind = "ready"       // player readied up
+	Unready     Kind = "unready"     // player unreadied
+	Connect     Kind = "connect"     // player connected to the game server
+	Disconnect  Kind = "disconnect"  // player disconnected from the game server
+	OwnerChange Kind = "ownerChange" // lobby leader changed
+	Start       Kind = "start"       // match started
+	Close       Kind = "close"       // lobby closed
+	Server      Kind = "server"      // something happened to the game server
+)
+
+//LobbyEvent is a single event in a lobby's timeline
+type LobbyEvent struct {
+	ID        uint      `json:"-"`
+	CreatedAt time.Time `json:"time"`
+	LobbyID   uint      `json:"-" sql:"index"`
+
+	Kind      Kind   `json:"kind"`
+	PlayerID  uint   `json:"-"`
+	SteamID   string `json:"steamid,omitempty"` // player the event is about
+	Name      string `json:"name,omitempty"`    // player's name at that time
+	BySteamID string `json:"by,omitempty"`      // player who did it (for kicks, bans, etc)
+	Detail    string `json:"detail,omitempty"`
+}
+
+//Log adds an event to the lobby's timeline, p can be nil for events
+//which aren't about a player
+func Log(lobbyID uint, kind Kind, p *player.Player, detail string) {
+	LogBy(lobbyID, kind, p, nil, detail)
+}
+
+//LogBy adds an event to the lobby's timeline, done by the player by
+func LogBy(lobbyID uint, kind Kind, p *player.Player, by *player.Player, detail string) {
+	event := &LobbyEvent{
+		LobbyID: lobbyID,
+		Kind:    kind,
+		Detail:  detail,
+	}
+	if p != nil {
+		event.PlayerID = p.ID
+		event.SteamID = p.SteamID
+		event.Name = p.Alias()
+	}
+	if by != nil {
+		event.BySteamID = by.SteamID
+	}
+
+	if err := db.DB.Create(event).Error; err != nil {
+		logrus.Error(err)
+	}
+}
+
+//Get returns the timeline for the given lobby, oldest event first
+func Get(lobbyID uint) []*LobbyEvent {
+	var events []*LobbyEvent
+	db.DB.Where("lobby_id = ?", lobbyID).Order("id asc").Find(&events)
+	return events
+}
+
+//GetPublic returns the timeline for the given lobby as shown to players who
+//can't view logs, without reports or who kicked and banned players
+func GetPublic(lobbyID uint) []*LobbyEvent {
+	var events []*LobbyEvent
+	db.DB.Where("lobby_id = ? AND kind <> ?", lobbyID, Report).Order("id asc").Find(&events)
+
+	for _, event := range events {
+		event.BySteamID = ""
+	}
+	return events
+}
+
+//GetPlayerEvents returns the last limit events about the player that
+//happened before the given time, newest first
+func GetPlayerEvents(playerID uint, before time.Time, limit int) []*LobbyEvent {
+	var events []*LobbyEvent
+	db.DB.Where("player_id = ? AND created_at <= ?", playerID, before).Order("id desc").Limit(limit).Find(&events)
+	return events
+}
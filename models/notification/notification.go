@@ -0,0 +1,100 @@
+//Package notification stores notifications for players, so they can see
+//what happened while they weren't connected
+package notification
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/TF2Stadium/Helen/controllers/broadcaster"
+	db "github.com/TF2Stadium/Helen/database"
+)
+
+type Kind string
+
+const (
+	Mention    Kind = "mention"    // player was mentioned in chat
+	SubRequest Kind = "subRequest" // a sub was requested for the player's slot
+	Kick       Kind = "kick"       // player was kicked or banned from a lobby
+	Ban        Kind = "ban"        // player was banned
+	Appeal     Kind = "appeal"     // player's ban appeal was decided
+	Report     Kind = "report"     // player's report was resolved
+)
+
+type Notification struct {
+	ID        uint      `json:"id"`
+	CreatedAt time.Time `json:"timestamp"`
+
+	PlayerID uint       `json:"-" sql:"index"`
+	Kind     Kind       `json:"kind"`
+	Message  string     `json:"message"`
+	Data     string     `json:"-"` // kind specific details, as JSON
+	ReadAt   *time.Time `json:"readAt,omitempty"`
+}
+
+func (n *Notification) MarshalJSON() ([]byte, error) {
+	type notification Notification // doesn't have the MarshalJSON method
+	data := json.RawMessage(n.Data)
+	if n.Data == "" {
+		data = nil
+	}
+
+	return json.Marshal(struct {
+		*notification
+		Data json.RawMessage `json:"data,omitempty"`
+	}{(*notification)(n), data})
+}
+
+//Send saves a notification for the player, and sends it to the player's sockets.
+//data is marshaled to JSON, and can be nil.
+func Send(playerID uint, steamid string, kind Kind, message string, data interface{}) *Notification {
+	n := &Notification{PlayerID: playerID, Kind: kind, Message: message}
+	if data != nil {
+		bytes, err := json.Marshal(data)
+		if err != nil {
+			logrus.Error(err)
+		}
+		n.Data = string(bytes)
+	}
+
+	if err := db.DB.Create(n).Error; err != nil {
+		logrus.Error(err)
+		return nil
+	}
+
+	broadcaster.SendMessage(steamid, "notification", n)
+	return n
+}
+
+//Get returns up to limit of the player's notifications with an ID lower than
+//before (if it isn't 0), newest first
+func Get(playerID uint, before uint, limit int) []*Notification {
+	var list []*Notification
+
+	query := db.DB.Where("player_id = ?", playerID)
+	if before != 0 {
+		query = query.Where("id < ?", before)
+	}
+	query.Order("id desc").Limit(limit).Find(&list)
+
+	return list
+}
+
+//UnreadCount returns the number of unread notifications the player has
+func UnreadCount(playerID uint) int {
+	var count int
+	db.DB.Model(&Notification{}).Where("player_id = ? AND read_at IS NULL", playerID).Count(&count)
+	return count
+}
+
+//MarkRead marks the player's notifications with the given IDs as read, or
+//all of them if ids is empty
+func MarkRead(playerID uint, ids []uint) error {
+	query := db.DB.Model(&Notification{}).Where("player_id = ? AND read_at IS NULL", playerID)
+	if len(ids) != 0 {
+		query = query.Where("id IN (?)", ids)
+	}
+
+	return query.Update("read_at", time.Now()).Error
+}
@@ -0,0 +1,40 @@
+package notification_test
+
+import (
+	"testing"
+
+	_ "github.com/TF2Stadium/Helen/helpers"
+	"github.com/TF2Stadium/Helen/internal/testhelpers"
+	. "github.com/TF2Stadium/Helen/models/notification"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	testhelpers.CleanupDB()
+}
+
+func TestNotifications(t *testing.T) {
+	t.Parallel()
+	p := testhelpers.CreatePlayer()
+
+	first := Send(p.ID, p.SteamID, Mention, "hi", struct {
+		Room int `json:"room"`
+	}{3})
+	if assert.NotNil(t, first) {
+		assert.Equal(t, `{"room":3}`, first.Data)
+	}
+	second := Send(p.ID, p.SteamID, Ban, "banned", nil)
+	assert.NotNil(t, second)
+	assert.Equal(t, 2, UnreadCount(p.ID))
+
+	list := Get(p.ID, 0, 10)
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, second.ID, list[0].ID)
+		assert.Len(t, Get(p.ID, list[0].ID, 10), 1)
+	}
+
+	assert.NoError(t, MarkRead(p.ID, []uint{first.ID}))
+	assert.Equal(t, 1, UnreadCount(p.ID))
+	assert.NoError(t, MarkRead(p.ID, nil))
+	assert.Zero(t, UnreadCount(p.ID))
+}
@@ -49,6 +49,8 @@ type Player struct {
 	Name       string             `json:"name"`              // Player name
 	Role       authority.AuthRole `sql:"default:0" json:"-"` // Role is player by default
 
+	AutoBanExempt bool `sql:"default:false" json:"-"` // never banned automatically for reports
+
 	Settings postgres.Hstore `json:"-"`
 
 	MumbleUsername string `sql:"unique"`
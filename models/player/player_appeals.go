@@ -0,0 +1,162 @@
+package player
+
+import (
+	"errors"
+	"time"
+
+	db "github.com/TF2Stadium/Helen/database"
+)
+
+type AppealStatus string
+
+const (
+	AppealPending  AppealStatus = "pending"
+	AppealAccepted AppealStatus = "accepted" // ban was lifted
+	AppealReduced  AppealStatus = "reduced"  // ban was shortened
+	AppealRejected AppealStatus = "rejected"
+)
+
+//BanAppeal is a banned player's request to lift or shorten a ban.
+//Players can only appeal each ban once.
+type BanAppeal struct {
+	ID        uint      `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	BanID    uint   `json:"banID" sql:"not null;unique"`
+	PlayerID uint   `json:"-" sql:"index"`
+	Message  string `json:"message"`
+
+	Status     AppealStatus `json:"status"`
+	ReviewedBy uint         `json:"-"`                  // ID of the mod who decided
+	Response   string       `json:"response,omitempty"` // mod's message to the player
+	ReviewedAt *time.Time   `json:"reviewedAt,omitempty"`
+}
+
+var (
+	ErrAppealNoBan   = errors.New("You don't have an active ban with that ID")
+	ErrAppealDupe    = errors.New("You have already appealed this ban")
+	ErrAppealLength  = errors.New("Appeals must be between 1 and 1000 characters long")
+	ErrAppealDecided = errors.New("Appeal has already been decided")
+	ErrAppealReduce  = errors.New("A reduced ban has to end before the current one, and after now")
+)
+
+//AppealBan files an appeal for the player's ban with the given ID
+func (player *Player) AppealBan(banID uint, message string) (*BanAppeal, error) {
+	if len(message) == 0 || len(message) > 1000 {
+		return nil, ErrAppealLength
+	}
+
+	var count int
+	db.DB.Model(&PlayerBan{}).Where("id = ? AND player_id = ? AND active = TRUE AND until > now()", banID, player.ID).Count(&count)
+	if count == 0 {
+		return nil, ErrAppealNoBan
+	}
+
+	db.DB.Model(&BanAppeal{}).Where("ban_id = ?", banID).Count(&count)
+	if count != 0 {
+		return nil, ErrAppealDupe
+	}
+
+	appeal := &BanAppeal{
+		BanID:    banID,
+		PlayerID: player.ID,
+		Message:  message,
+		Status:   AppealPending,
+	}
+	return appeal, db.DB.Create(appeal).Error
+}
+
+//GetAppeals returns every appeal the player has filed, newest first
+func (player *Player) GetAppeals() []*BanAppeal {
+	var appeals []*BanAppeal
+	db.DB.Where("player_id = ?", player.ID).Order("id desc").Find(&appeals)
+	return appeals
+}
+
+//GetAppeal returns the appeal with the given ID
+func GetAppeal(id uint) (*BanAppeal, error) {
+	appeal := &BanAppeal{}
+	err := db.DB.First(appeal, id).Error
+	return appeal, err
+}
+
+//GetAppeals returns appeals with the given status, oldest first
+func GetAppeals(status AppealStatus) []*BanAppeal {
+	var appeals []*BanAppeal
+	db.DB.Where("status = ?", status).Order("id").Find(&appeals)
+	return appeals
+}
+
+//GetBan returns the ban the appeal is for
+func (a *BanAppeal) GetBan() (*PlayerBan, error) {
+	ban := &PlayerBan{}
+	err := db.DB.Preload("Player").Preload("BannedByPlayer").First(ban, a.BanID).Error
+	return ban, err
+}
+
+func (a *BanAppeal) decide(status AppealStatus, modID uint, response string) error {
+	now := time.Now()
+	a.Status = status
+	a.ReviewedBy = modID
+	a.Response = response
+	a.ReviewedAt = &now
+	return db.DB.Save(a).Error
+}
+
+//getActiveBan returns the ban the appeal is for, or ErrAppealNoBan if it
+//has expired or been lifted since
+func (a *BanAppeal) getActiveBan() (*PlayerBan, error) {
+	ban, err := a.GetBan()
+	if err != nil {
+		return nil, err
+	}
+	if !ban.Active || ban.Until.Before(time.Now()) {
+		return nil, ErrAppealNoBan
+	}
+	return ban, nil
+}
+
+//Accept lifts the ban. Other bans the player has, even of the same type, are kept.
+func (a *BanAppeal) Accept(modID uint, response string) error {
+	if a.Status != AppealPending {
+		return ErrAppealDecided
+	}
+	ban, err := a.getActiveBan()
+	if err != nil {
+		return err
+	}
+
+	if err := db.DB.Model(ban).Update("active", false).Error; err != nil {
+		return err
+	}
+	ban.Player.InvalidateSessions()
+	return a.decide(AppealAccepted, modID, response)
+}
+
+//Reduce shortens the ban so it ends at until
+func (a *BanAppeal) Reduce(modID uint, until time.Time, response string) error {
+	if a.Status != AppealPending {
+		return ErrAppealDecided
+	}
+	ban, err := a.getActiveBan()
+	if err != nil {
+		return err
+	}
+	if !until.Before(ban.Until) || until.Before(time.Now()) {
+		return ErrAppealReduce
+	}
+
+	if err := db.DB.Model(ban).Update("until", until).Error; err != nil {
+		return err
+	}
+	ban.Player.InvalidateSessions()
+	return a.decide(AppealReduced, modID, response)
+}
+
+//Reject keeps the ban as is
+func (a *BanAppeal) Reject(modID uint, response string) error {
+	if a.Status != AppealPending {
+		return ErrAppealDecided
+	}
+	return a.decide(AppealRejected, modID, response)
+}
@@ -0,0 +1,86 @@
+package player_test
+
+import (
+	"testing"
+	"time"
+
+	db "github.com/TF2Stadium/Helen/database"
+	"github.com/TF2Stadium/Helen/internal/testhelpers"
+	. "github.com/TF2Stadium/Helen/models/player"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	testhelpers.CleanupDB()
+}
+
+func TestAppealBan(t *testing.T) {
+	t.Parallel()
+	p := testhelpers.CreatePlayer()
+
+	_, err := p.AppealBan(1000, "please")
+	assert.Equal(t, ErrAppealNoBan, err)
+
+	p.BanUntil(time.Now().Add(48*time.Hour), BanJoin, "ragequit", 0)
+	ban, err := p.GetActiveBan(BanJoin)
+	assert.NoError(t, err)
+
+	_, err = p.AppealBan(ban.ID, "")
+	assert.Equal(t, ErrAppealLength, err)
+
+	appeal, err := p.AppealBan(ban.ID, "my internet died")
+	assert.NoError(t, err)
+	assert.Equal(t, AppealPending, appeal.Status)
+
+	_, err = p.AppealBan(ban.ID, "again")
+	assert.Equal(t, ErrAppealDupe, err)
+
+	assert.Equal(t, ErrAppealReduce, appeal.Reduce(1, time.Now().Add(72*time.Hour), ""))
+	until := time.Now().Add(time.Hour)
+	assert.NoError(t, appeal.Reduce(1, until, "shortened"))
+	assert.Equal(t, ErrAppealDecided, appeal.Accept(1, ""))
+
+	ban, _ = p.GetActiveBan(BanJoin)
+	assert.WithinDuration(t, until, ban.Until, time.Second)
+
+	appeal, _ = GetAppeal(appeal.ID)
+	assert.Equal(t, AppealReduced, appeal.Status)
+	assert.Equal(t, "shortened", appeal.Response)
+	assert.Len(t, p.GetAppeals(), 1)
+}
+
+func TestAcceptAppeal(t *testing.T) {
+	t.Parallel()
+	p := testhelpers.CreatePlayer()
+
+	p.BanUntil(time.Now().Add(48*time.Hour), BanChat, "spam", 0)
+	ban, _ := p.GetActiveBan(BanChat)
+	appeal, err := p.AppealBan(ban.ID, "wasn't me")
+	assert.NoError(t, err)
+
+	assert.NoError(t, appeal.Accept(1, "sorry"))
+	assert.False(t, p.IsBanned(BanChat))
+}
+
+func TestAcceptAppealOtherBans(t *testing.T) {
+	t.Parallel()
+	p := testhelpers.CreatePlayer()
+
+	p.BanUntil(time.Now().Add(48*time.Hour), BanCreate, "griefing", 0)
+	ban, _ := p.GetActiveBan(BanCreate)
+	appeal, err := p.AppealBan(ban.ID, "wasn't me")
+	assert.NoError(t, err)
+
+	// a newer ban of the same type isn't lifted with the appealed one
+	newer := &PlayerBan{PlayerID: p.ID, Type: BanCreate, Until: time.Now().Add(96 * time.Hour), Active: true}
+	assert.NoError(t, db.DB.Create(newer).Error)
+
+	assert.NoError(t, appeal.Accept(1, ""))
+	assert.True(t, p.IsBanned(BanCreate))
+
+	// bans lifted after they were appealed can't be lifted again
+	other, err := p.AppealBan(newer.ID, "this one too")
+	assert.NoError(t, err)
+	p.Unban(BanCreate)
+	assert.Equal(t, ErrAppealNoBan, other.Accept(1, ""))
+}
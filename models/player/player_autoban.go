@@ -0,0 +1,163 @@
+package player
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/TF2Stadium/Helen/assets"
+	db "github.com/TF2Stadium/Helen/database"
+)
+
+var reportTypeNames = map[string]ReportType{
+	"sub":      Substitute,
+	"vote":     Vote,
+	"rageQuit": RageQuit,
+}
+
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(str)
+	*d = duration(parsed)
+	return err
+}
+
+//String formats d without trailing zero units, like 2h instead of 2h0m0s
+func (d duration) String() string {
+	str := time.Duration(d).String()
+	if strings.HasSuffix(str, "m0s") {
+		str = str[:len(str)-2]
+	}
+	if strings.HasSuffix(str, "h0m") {
+		str = str[:len(str)-2]
+	}
+	return str
+}
+
+//AutoBanRule bans players who get Count reports of the same type within Window
+type AutoBanRule struct {
+	Name     string     `json:"name"`
+	TypeName string     `json:"type"` // sub, vote or rageQuit
+	Type     ReportType `json:"-"`
+	Count    int        `json:"count"`
+	Window   duration   `json:"window"`
+	Reason   string     `json:"reason"` // what the player did, used in the ban's reason
+}
+
+//AutoBanPolicy decides when players get banned from joining lobbies for
+//the reports they get. Players banned again before their previous automatic
+//bans decay get longer bans.
+type AutoBanPolicy struct {
+	Rules     []AutoBanRule `json:"rules"`
+	Durations []duration    `json:"durations"` // ban duration for the first offense, second one, etc. The last one is used after that.
+	Decay     duration      `json:"decay"`     // automatic bans older than this don't count as prior offenses
+}
+
+var policy *AutoBanPolicy
+
+func init() {
+	// the policy embedded at build time, main replaces it if AUTOBAN_POLICY is set
+	if err := LoadAutoBanPolicy(assets.AutoBanPolicyJSON); err != nil {
+		panic(err)
+	}
+}
+
+//LoadAutoBanPolicy parses and sets the policy used for automatic bans
+func LoadAutoBanPolicy(data []byte) error {
+	p := &AutoBanPolicy{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return err
+	}
+
+	if len(p.Durations) == 0 {
+		return errors.New("autoban: at least one ban duration is needed")
+	}
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		rtype, ok := reportTypeNames[rule.TypeName]
+		if !ok {
+			return fmt.Errorf("autoban: rule %q has invalid report type %q", rule.Name, rule.TypeName)
+		}
+		if rule.Count < 1 || rule.Window <= 0 {
+			return fmt.Errorf("autoban: rule %q needs a positive count and window", rule.Name)
+		}
+		rule.Type = rtype
+	}
+
+	policy = p
+	return nil
+}
+
+//check returns the first rule for rtype which fires, count returns the number
+//of reports of that type the player got in the given window
+func (p *AutoBanPolicy) check(rtype ReportType, count func(window time.Duration) int) *AutoBanRule {
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if rule.Type == rtype && count(time.Duration(rule.Window)) >= rule.Count {
+			return rule
+		}
+	}
+	return nil
+}
+
+//duration returns how long the ban for a player with the given number of
+//prior (undecayed) automatic bans lasts
+func (p *AutoBanPolicy) duration(prior int) time.Duration {
+	if prior >= len(p.Durations) {
+		prior = len(p.Durations) - 1
+	}
+	return time.Duration(p.Durations[prior])
+}
+
+//reason explains which rule fired
+func (p *AutoBanPolicy) reason(rule *AutoBanRule, prior int) string {
+	reason := fmt.Sprintf("For %s %d times in %s (rule %q)", rule.Reason, rule.Count, rule.Window, rule.Name)
+	if prior != 0 {
+		reason += fmt.Sprintf(", automatic ban #%d in the last %s", prior+1, p.Decay)
+	}
+	return reason
+}
+
+//autoBan bans the player from joining lobbies if any of the policy's rules
+//fire after getting a report of type rtype
+func (player *Player) autoBan(rtype ReportType) {
+	if player.AutoBanExempt {
+		return
+	}
+	now := time.Now()
+
+	// reports that already got the player banned don't count again
+	var since time.Time
+	last := &PlayerBan{}
+	err := db.DB.Where("player_id = ? AND banned_by_player_id = 0 AND type = ?", player.ID, BanJoin).Order("created_at desc").First(last).Error
+	if err == nil {
+		since = last.CreatedAt
+	}
+
+	rule := policy.check(rtype, func(window time.Duration) int {
+		from := now.Add(-window)
+		if since.After(from) {
+			from = since
+		}
+
+		var count int
+		db.DB.Model(&Report{}).Where("player_id = ? AND created_at > ? AND type = ?", player.ID, from, rtype).Count(&count)
+		return count
+	})
+	if rule == nil {
+		return
+	}
+
+	var prior int
+	db.DB.Model(&PlayerBan{}).Where("player_id = ? AND banned_by_player_id = 0 AND type = ? AND created_at > ?",
+		player.ID, BanJoin, now.Add(-time.Duration(policy.Decay))).Count(&prior)
+
+	player.BanUntil(now.Add(policy.duration(prior)), BanJoin, policy.reason(rule, prior), 0)
+}
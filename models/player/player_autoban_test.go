@@ -0,0 +1,63 @@
+package player
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadAutoBanPolicy(t *testing.T) {
+	assert.Error(t, LoadAutoBanPolicy([]byte(`{"rules": [], "durations": []}`)))
+	assert.Error(t, LoadAutoBanPolicy([]byte(`{"rules": [{"type": "afk", "count": 1, "window": "1h"}], "durations": ["1h"]}`)))
+	assert.Error(t, LoadAutoBanPolicy([]byte(`{"rules": [{"type": "sub", "count": 0, "window": "1h"}], "durations": ["1h"]}`)))
+	assert.Error(t, LoadAutoBanPolicy([]byte(`{"rules": [], "durations": ["soon"]}`)))
+
+	old := policy
+	defer func() { policy = old }()
+
+	err := LoadAutoBanPolicy([]byte(`{"rules": [{"name": "x", "type": "rageQuit", "count": 3, "window": "2h"}], "durations": ["1h"], "decay": "24h"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, RageQuit, policy.Rules[0].Type)
+	assert.Equal(t, 2*time.Hour, time.Duration(policy.Rules[0].Window))
+}
+
+func TestAutoBanPolicy(t *testing.T) {
+	p := &AutoBanPolicy{
+		Rules: []AutoBanRule{
+			{Name: "subs", Type: Substitute, Count: 2, Window: duration(30 * time.Minute), Reason: "!subbing"},
+			{Name: "serial subs", Type: Substitute, Count: 4, Window: duration(24 * time.Hour), Reason: "!subbing"},
+		},
+		Durations: []duration{duration(30 * time.Minute), duration(2 * time.Hour), duration(24 * time.Hour)},
+		Decay:     duration(168 * time.Hour),
+	}
+
+	// one sub in the last 30 minutes, three in the last day
+	count := func(window time.Duration) int {
+		if window <= 30*time.Minute {
+			return 1
+		}
+		return 3
+	}
+	assert.Nil(t, p.check(Substitute, count))
+	assert.Nil(t, p.check(RageQuit, func(time.Duration) int { return 10 }))
+
+	rule := p.check(Substitute, func(window time.Duration) int { return 4 })
+	assert.Equal(t, "subs", rule.Name)
+	rule = p.check(Substitute, func(window time.Duration) int {
+		if window <= 30*time.Minute {
+			return 0
+		}
+		return 4
+	})
+	assert.Equal(t, "serial subs", rule.Name)
+
+	assert.Equal(t, 30*time.Minute, p.duration(0))
+	assert.Equal(t, 2*time.Hour, p.duration(1))
+	assert.Equal(t, 24*time.Hour, p.duration(2))
+	assert.Equal(t, 24*time.Hour, p.duration(5))
+
+	assert.Equal(t, `For !subbing 4 times in 24h (rule "serial subs")`, p.reason(rule, 0))
+	assert.Equal(t, `For !subbing 4 times in 24h (rule "serial subs"), automatic ban #3 in the last 168h`, p.reason(rule, 2))
+	assert.Equal(t, "1h30m", duration(90*time.Minute).String())
+}
@@ -1,9 +1,11 @@
 package player
 
 import (
+	"fmt"
 	"time"
 
 	db "github.com/TF2Stadium/Helen/database"
+	"github.com/TF2Stadium/Helen/models/notification"
 	"github.com/jinzhu/gorm"
 )
 
@@ -59,8 +61,11 @@ func (player *Player) IsBanned(t BanType) bool {
 
 func (player *Player) BanUntil(tim time.Time, t BanType, reason string, bannedBy uint) error {
 	// first check if player is already banned
+	defer player.InvalidateSessions()
+
 	if banned := player.IsBanned(t); banned {
 		db.DB.Model(&PlayerBan{}).Where("player_id = ? AND type = ? AND active = TRUE AND until > now()", player.ID, t).Update("until", tim)
+		player.notifyBan(t, tim, reason)
 		return nil
 	}
 	ban := PlayerBan{
@@ -71,10 +76,26 @@ func (player *Player) BanUntil(tim time.Time, t BanType, reason string, bannedBy
 		BannedByPlayerID: bannedBy,
 	}
 
-	return db.DB.Create(&ban).Error
+	if err := db.DB.Create(&ban).Error; err != nil {
+		return err
+	}
+	player.notifyBan(t, tim, reason)
+	return nil
+}
+
+func (player *Player) notifyBan(t BanType, until time.Time, reason string) {
+	notification.Send(player.ID, player.SteamID, notification.Ban,
+		fmt.Sprintf("You have received a %s till %s (%s)", t.String(), until.Format(time.RFC822), reason),
+		struct {
+			Type   string    `json:"type"`
+			Until  time.Time `json:"until"`
+			Reason string    `json:"reason"`
+		}{t.String(), until, reason})
 }
 
 func (player *Player) Unban(t BanType) error {
+	defer player.InvalidateSessions()
+
 	return db.DB.Model(&PlayerBan{}).Where("player_id = ? AND type = ? AND active = TRUE", player.ID, t).
 		Update("active", "FALSE").Error
 }
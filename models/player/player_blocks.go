@@ -0,0 +1,71 @@
+package player
+
+import (
+	"errors"
+	"time"
+
+	db "github.com/TF2Stadium/Helen/database"
+)
+
+//Block hides BlockedID from PlayerID
+type Block struct {
+	ID        uint
+	CreatedAt time.Time
+
+	PlayerID  uint `sql:"index"`
+	BlockedID uint `sql:"index"`
+}
+
+func (Block) TableName() string { return "player_blocks" }
+
+var (
+	ErrBlockSelf = errors.New("You can't block yourself")
+	ErrBlockDupe = errors.New("You've already blocked this player")
+)
+
+//Block adds other to the player's block list
+func (player *Player) Block(other *Player) error {
+	if other.ID == player.ID {
+		return ErrBlockSelf
+	}
+	if player.HasBlocked(other.ID) {
+		return ErrBlockDupe
+	}
+
+	return db.DB.Create(&Block{PlayerID: player.ID, BlockedID: other.ID}).Error
+}
+
+//Unblock removes other from the player's block list
+func (player *Player) Unblock(other *Player) error {
+	return db.DB.Where("player_id = ? AND blocked_id = ?", player.ID, other.ID).Delete(&Block{}).Error
+}
+
+//HasBlocked returns whether the player has blocked the player with the given ID
+func (player *Player) HasBlocked(id uint) bool {
+	var count int
+	db.DB.Model(&Block{}).Where("player_id = ? AND blocked_id = ?", player.ID, id).Count(&count)
+	return count != 0
+}
+
+//GetBlocked returns the players the player has blocked
+func (player *Player) GetBlocked() []*Player {
+	var players []*Player
+	db.DB.Table("players").Joins("INNER JOIN player_blocks ON player_blocks.blocked_id = players.id").
+		Where("player_blocks.player_id = ?", player.ID).Order("player_blocks.id").Find(&players)
+	return players
+}
+
+//GetBlockedIDs returns the IDs of the players the player has blocked
+func (player *Player) GetBlockedIDs() []uint {
+	var ids []uint
+	db.DB.Model(&Block{}).Where("player_id = ?", player.ID).Pluck("blocked_id", &ids)
+	return ids
+}
+
+//GetBlockerSteamIDs returns the Steam IDs of the players who have blocked the player with the given ID
+func GetBlockerSteamIDs(id uint) []string {
+	var steamids []string
+	db.DB.Table("players").Joins("INNER JOIN player_blocks ON player_blocks.player_id = players.id").
+		Where("player_blocks.blocked_id = ?", id).Pluck("players.steam_id", &steamids)
+	return steamids
+}
@@ -0,0 +1,34 @@
+package player_test
+
+import (
+	"testing"
+
+	"github.com/TF2Stadium/Helen/internal/testhelpers"
+	. "github.com/TF2Stadium/Helen/models/player"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlock(t *testing.T) {
+	t.Parallel()
+	p := testhelpers.CreatePlayer()
+	other := testhelpers.CreatePlayer()
+
+	assert.Equal(t, ErrBlockSelf, p.Block(p))
+	assert.NoError(t, p.Block(other))
+	assert.Equal(t, ErrBlockDupe, p.Block(other))
+
+	assert.True(t, p.HasBlocked(other.ID))
+	assert.False(t, other.HasBlocked(p.ID))
+
+	blocked := p.GetBlocked()
+	if assert.Len(t, blocked, 1) {
+		assert.Equal(t, other.ID, blocked[0].ID)
+	}
+	assert.Equal(t, []uint{other.ID}, p.GetBlockedIDs())
+	assert.Equal(t, []string{p.SteamID}, GetBlockerSteamIDs(other.ID))
+	assert.Empty(t, GetBlockerSteamIDs(p.ID))
+
+	assert.NoError(t, p.Unblock(other))
+	assert.False(t, p.HasBlocked(other.ID))
+	assert.Empty(t, p.GetBlocked())
+}
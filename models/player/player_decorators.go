@@ -9,11 +9,10 @@ import (
 	"time"
 
 	db "github.com/TF2Stadium/Helen/database"
-	"github.com/TF2Stadium/Helen/helpers"
 )
 
 func (p *Player) DecoratePlayerTags() []string {
-	tags := []string{helpers.RoleNames[p.Role]}
+	tags := []string{p.Role.Name()}
 	if p.IsStreaming {
 		tags = append(tags, "twitch")
 	}
@@ -33,7 +32,7 @@ func (p *Player) setJSONFields(stats, lobbies, streaming, bans bool) {
 	p.PlaceholderTags = new([]string)
 	p.PlaceholderRoleStr = new(string)
 
-	*p.PlaceholderRoleStr = helpers.RoleNames[p.Role]
+	*p.PlaceholderRoleStr = p.Role.Name()
 	*p.PlaceholderTags = p.DecoratePlayerTags()
 
 	// if lobbies {
@@ -0,0 +1,227 @@
+package player
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/TF2Stadium/Helen/config"
+	db "github.com/TF2Stadium/Helen/database"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	IPLogin  = "login"  // logged in through Steam
+	IPSocket = "socket" // connected to the websocket
+)
+
+//IPRecord is an address a player logged in or connected from. Only a keyed
+//hash of the address is stored, which is enough to tell accounts apart.
+type IPRecord struct {
+	ID        uint
+	CreatedAt time.Time
+	LastSeen  time.Time `sql:"index"`
+
+	PlayerID uint   `sql:"index"`
+	IPHash   string `sql:"index"`
+	Source   string
+}
+
+func (IPRecord) TableName() string { return "player_ips" }
+
+type AltStatus string
+
+const (
+	AltOpen      AltStatus = "open"
+	AltConfirmed AltStatus = "confirmed"
+	AltDismissed AltStatus = "dismissed"
+)
+
+//AltFlag links a new or banned account to a banned account it shares IPs
+//with, for mods to confirm or dismiss.
+type AltFlag struct {
+	ID        uint
+	CreatedAt time.Time
+
+	PlayerID uint `sql:"index"` // the suspected alt
+	LinkedID uint `sql:"index"` // the banned account
+	Shared   int  // number of addresses they share
+
+	Status     AltStatus
+	ReviewedBy uint
+}
+
+var ErrAltReviewed = errors.New("Flag has already been reviewed")
+
+//hashIP returns a keyed hash of ip, so addresses can't be recovered from the database
+func hashIP(ip string) string {
+	mac := hmac.New(sha256.New, []byte(config.Constants.CookieStoreSecret))
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+//RecordIP records that the player used the given address, and flags
+//the player if it's shared with a banned account
+func (player *Player) RecordIP(ip, source string) {
+	hash := hashIP(ip)
+	now := time.Now()
+
+	result := db.DB.Model(&IPRecord{}).Where("player_id = ? AND ip_hash = ? AND source = ?", player.ID, hash, source).Update("last_seen", now)
+	if result.Error != nil {
+		logrus.Error(result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		record := &IPRecord{LastSeen: now, PlayerID: player.ID, IPHash: hash, Source: source}
+		if err := db.DB.Create(record).Error; err != nil {
+			logrus.Error(err)
+			return
+		}
+	}
+
+	player.checkAlts()
+}
+
+//isSuspect returns true if the player's account should be checked for
+//shared addresses: new accounts and banned accounts
+func (player *Player) isSuspect() bool {
+	if time.Since(player.CreatedAt) < config.Constants.AltAccountAge {
+		return true
+	}
+	bans, _ := player.GetActiveBans()
+	return len(bans) != 0
+}
+
+//sharedIPs returns the number of addresses each other player shares with this one
+func (player *Player) sharedIPs() map[uint]int {
+	rows, err := db.DB.Raw(`SELECT other.player_id, COUNT(DISTINCT other.ip_hash) FROM player_ips AS mine
+		INNER JOIN player_ips AS other ON other.ip_hash = mine.ip_hash AND other.player_id <> mine.player_id
+		WHERE mine.player_id = ? GROUP BY other.player_id`, player.ID).Rows()
+	if err != nil {
+		logrus.Error(err)
+		return nil
+	}
+	defer rows.Close()
+
+	shared := make(map[uint]int)
+	for rows.Next() {
+		var id uint
+		var count int
+		rows.Scan(&id, &count)
+		shared[id] = count
+	}
+	return shared
+}
+
+//checkAlts flags the player if it's a new or banned account sharing
+//addresses with an account which is actively banned
+func (player *Player) checkAlts() {
+	if !player.isSuspect() {
+		return
+	}
+
+	for id, count := range player.sharedIPs() {
+		var bans int
+		db.DB.Model(&PlayerBan{}).Where("player_id = ? AND active = TRUE AND until > now()", id).Count(&bans)
+		if bans == 0 {
+			continue
+		}
+
+		flag := &AltFlag{}
+		err := db.DB.Where("player_id = ? AND linked_id = ?", player.ID, id).First(flag).Error
+		if err == nil {
+			// already flagged, keep the count up to date
+			db.DB.Model(flag).Update("shared", count)
+			continue
+		}
+
+		flag = &AltFlag{PlayerID: player.ID, LinkedID: id, Shared: count, Status: AltOpen}
+		if err := db.DB.Create(flag).Error; err != nil {
+			logrus.Error(err)
+		}
+	}
+}
+
+//GetLinkedAccounts returns the IDs of players sharing addresses with this one,
+//with the number of addresses they share
+func (player *Player) GetLinkedAccounts() map[uint]int {
+	return player.sharedIPs()
+}
+
+//GetAltFlags returns flags with the given status, newest first
+func GetAltFlags(status AltStatus) []*AltFlag {
+	var flags []*AltFlag
+	db.DB.Where("status = ?", status).Order("id desc").Find(&flags)
+	return flags
+}
+
+//GetAltFlag returns the flag with the given ID
+func GetAltFlag(id uint) (*AltFlag, error) {
+	flag := &AltFlag{}
+	err := db.DB.First(flag, id).Error
+	return flag, err
+}
+
+//Dismiss marks the accounts as unrelated
+func (f *AltFlag) Dismiss(modID uint) error {
+	if f.Status != AltOpen {
+		return ErrAltReviewed
+	}
+
+	f.Status = AltDismissed
+	f.ReviewedBy = modID
+	return db.DB.Save(f).Error
+}
+
+//Confirm marks the flagged player as an alt of the linked account.
+//If banAlt is true, the alt gets the linked account's active bans.
+func (f *AltFlag) Confirm(modID uint, banAlt bool) error {
+	if f.Status != AltOpen {
+		return ErrAltReviewed
+	}
+
+	if banAlt {
+		alt, err := GetPlayerByID(f.PlayerID)
+		if err != nil {
+			return err
+		}
+		linked, err := GetPlayerByID(f.LinkedID)
+		if err != nil {
+			return err
+		}
+
+		bans, _ := linked.GetActiveBans()
+		for _, ban := range bans {
+			reason := fmt.Sprintf("Ban evasion (alt of %s): %s", linked.SteamID, ban.Reason)
+			if err := alt.BanUntil(ban.Until, ban.Type, reason, modID); err != nil {
+				return err
+			}
+		}
+	}
+
+	f.Status = AltConfirmed
+	f.ReviewedBy = modID
+	return db.DB.Save(f).Error
+}
+
+//DeleteOldIPs deletes addresses which haven't been seen for longer than maxAge
+func DeleteOldIPs(maxAge time.Duration) {
+	db.DB.Where("last_seen < ?", time.Now().Add(-maxAge)).Delete(&IPRecord{})
+}
+
+//StartIPRetention deletes old addresses every hour, in a goroutine
+func StartIPRetention() {
+	if config.Constants.IPRetention == 0 {
+		return
+	}
+
+	go func() {
+		for {
+			DeleteOldIPs(config.Constants.IPRetention)
+			time.Sleep(time.Hour)
+		}
+	}()
+}
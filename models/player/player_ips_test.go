@@ -0,0 +1,51 @@
+package player_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TF2Stadium/Helen/internal/testhelpers"
+	. "github.com/TF2Stadium/Helen/models/player"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	testhelpers.CleanupDB()
+}
+
+func TestAltDetection(t *testing.T) {
+	t.Parallel()
+	banned := testhelpers.CreatePlayer()
+	alt := testhelpers.CreatePlayer()
+	other := testhelpers.CreatePlayer()
+
+	banned.BanUntil(time.Now().Add(24*time.Hour), BanJoin, "griefing", 0)
+	banned.RecordIP("198.51.100.7", IPLogin)
+	banned.RecordIP("198.51.100.7", IPSocket)
+	other.RecordIP("198.51.100.8", IPLogin)
+
+	alt.RecordIP("198.51.100.8", IPLogin)
+	alt.RecordIP("198.51.100.7", IPSocket)
+	alt.RecordIP("198.51.100.7", IPSocket)
+
+	linked := alt.GetLinkedAccounts()
+	assert.Equal(t, 1, linked[banned.ID])
+	assert.Equal(t, 1, linked[other.ID])
+
+	// only the banned account gets flagged
+	var flag *AltFlag
+	for _, f := range GetAltFlags(AltOpen) {
+		if f.PlayerID == alt.ID {
+			assert.Equal(t, banned.ID, f.LinkedID)
+			flag = f
+		}
+	}
+	if assert.NotNil(t, flag) {
+		assert.NoError(t, flag.Confirm(1, true))
+		assert.Equal(t, ErrAltReviewed, flag.Dismiss(1))
+
+		ban, err := alt.GetActiveBan(BanJoin)
+		assert.NoError(t, err)
+		assert.Contains(t, ban.Reason, banned.SteamID)
+	}
+}
@@ -1,18 +1,33 @@
 package player
 
 import (
+	"errors"
+	"strconv"
+	"strings"
 	"time"
 
 	db "github.com/TF2Stadium/Helen/database"
 )
 
 type Report struct {
-	ID        uint `gorm:"primary_key"`
-	CreatedAt time.Time
+	ID        uint      `gorm:"primary_key" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
 
-	PlayerID uint
-	LobbyID  uint
-	Type     ReportType
+	PlayerID uint       `json:"-"`
+	LobbyID  uint       `json:"lobbyID"`
+	Type     ReportType `json:"-"`
+
+	// the following are only set for reports filed by players
+	ReporterID uint           `json:"-" sql:"index"`
+	Category   ReportCategory `json:"category"`
+	Details    string         `json:"details"`
+	ChatIDs    string         `json:"-"` // comma separated IDs of chat messages attached as evidence
+
+	Status     ReportStatus `json:"status"`
+	ClaimedBy  uint         `json:"-"` // ID of the mod handling the report
+	Outcome    string       `json:"outcome,omitempty"` // message sent to the reporter
+	BanID      uint         `json:"-"` // PlayerBan given for this report, if any
+	ResolvedAt *time.Time   `json:"resolvedAt,omitempty"`
 }
 
 type ReportType int
@@ -21,34 +36,200 @@ const (
 	Substitute ReportType = iota //!sub
 	Vote                         //!repped by other players
 	RageQuit                     //rage quit
+	Filed                        //filed by another player
 )
 
-func (player *Player) NewReport(rtype ReportType, lobbyid uint) {
-	var count int
+type ReportCategory string
 
-	last := time.Now().Add(-30 * time.Minute)
-	db.DB.Model(&Report{}).Where("player_id = ? AND created_at > ? AND type = ?", player.ID, last, rtype).Count(&count)
+const (
+	Griefing ReportCategory = "griefing"
+	Cheating ReportCategory = "cheating"
+	Toxicity ReportCategory = "toxicity"
+	NoShow   ReportCategory = "noShow"
+)
 
-	switch rtype {
-	case Substitute:
-		if count == 1 {
-			player.BanUntil(time.Now().Add(30*time.Minute), BanJoin, "For !subbing twice in the last 30 minutes", 0)
-		}
-	case Vote:
-		if count != 0 {
-			player.BanUntil(time.Now().Add(30*time.Minute), BanJoin, "For getting !repped from a lobby multiple times in the last 30 minutes", 0)
-		}
-	case RageQuit:
-		if count != 0 {
-			player.BanUntil(time.Now().Add(30*time.Minute), BanJoin, "For ragequitting a lobby multiple times in the last 30 minutes", 0)
-		}
+var ReportCategories = []ReportCategory{Griefing, Cheating, Toxicity, NoShow}
+
+type ReportStatus string
+
+const (
+	ReportOpen     ReportStatus = "open"
+	ReportClaimed  ReportStatus = "claimed"
+	ReportResolved ReportStatus = "resolved"
+)
+
+//maximum number of open reports a player can have filed at once
+const maxOpenReports = 5
+
+var (
+	ErrReportCategory = errors.New("Invalid report category")
+	ErrReportSelf     = errors.New("You can't report yourself")
+	ErrReportLimit    = errors.New("You have too many open reports, wait for a moderator to handle them")
+	ErrReportDupe     = errors.New("You have already reported this player")
+	ErrReportClaimed  = errors.New("Report is already being handled by another moderator")
+	ErrReportResolved = errors.New("Report has already been resolved")
+)
 
+func validCategory(category ReportCategory) bool {
+	for _, c := range ReportCategories {
+		if c == category {
+			return true
+		}
 	}
+	return false
+}
 
+//NewReport records an automatic report, and bans the player if the
+//auto-ban policy says so
+func (player *Player) NewReport(rtype ReportType, lobbyid uint) {
 	r := &Report{
 		LobbyID:  lobbyid,
 		PlayerID: player.ID,
 		Type:     rtype,
 	}
 	db.DB.Save(r)
+
+	player.autoBan(rtype)
+}
+
+//FileReport files a report against target, chatIDs are the IDs of chat
+//messages attached as evidence
+func (player *Player) FileReport(target *Player, category ReportCategory, lobbyID uint, chatIDs []uint, details string) (*Report, error) {
+	if !validCategory(category) {
+		return nil, ErrReportCategory
+	}
+	if player.ID == target.ID {
+		return nil, ErrReportSelf
+	}
+
+	var count int
+	db.DB.Model(&Report{}).Where("reporter_id = ? AND status <> ?", player.ID, ReportResolved).Count(&count)
+	if count >= maxOpenReports {
+		return nil, ErrReportLimit
+	}
+	db.DB.Model(&Report{}).Where("reporter_id = ? AND player_id = ? AND lobby_id = ? AND status <> ?",
+		player.ID, target.ID, lobbyID, ReportResolved).Count(&count)
+	if count != 0 {
+		return nil, ErrReportDupe
+	}
+
+	ids := make([]string, len(chatIDs))
+	for i, id := range chatIDs {
+		ids[i] = strconv.FormatUint(uint64(id), 10)
+	}
+
+	report := &Report{
+		PlayerID:   target.ID,
+		LobbyID:    lobbyID,
+		Type:       Filed,
+		ReporterID: player.ID,
+		Category:   category,
+		Details:    details,
+		ChatIDs:    strings.Join(ids, ","),
+		Status:     ReportOpen,
+	}
+	return report, db.DB.Create(report).Error
+}
+
+//ChatIDList returns the IDs of the chat messages attached to the report
+func (r *Report) ChatIDList() []uint {
+	var ids []uint
+	for _, str := range strings.Split(r.ChatIDs, ",") {
+		id, err := strconv.ParseUint(str, 10, 32)
+		if err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
+
+//GetReport returns the report with the given ID
+func GetReport(id uint) (*Report, error) {
+	report := &Report{}
+	err := db.DB.First(report, id).Error
+	return report, err
+}
+
+//GetFiledReports returns reports filed by players with the given status,
+//oldest first. An empty status returns every unresolved report.
+func GetFiledReports(status ReportStatus) []*Report {
+	var reports []*Report
+	query := db.DB.Where("type = ?", Filed)
+	if status == "" {
+		query = query.Where("status <> ?", ReportResolved)
+	} else {
+		query = query.Where("status = ?", status)
+	}
+	query.Order("id").Find(&reports)
+	return reports
+}
+
+//GetReportsFiled returns the reports filed by the player, newest first
+func (player *Player) GetReportsFiled() []*Report {
+	var reports []*Report
+	db.DB.Where("type = ? AND reporter_id = ?", Filed, player.ID).Order("id desc").Find(&reports)
+	return reports
+}
+
+//GetReportsAgainst returns every report filed against the player, newest first
+func (player *Player) GetReportsAgainst() []*Report {
+	var reports []*Report
+	db.DB.Where("type = ? AND player_id = ?", Filed, player.ID).Order("id desc").Find(&reports)
+	return reports
+}
+
+//CanHandle returns an error if the given mod can't claim or resolve the
+//report, because it's resolved or claimed by another mod
+func (r *Report) CanHandle(modID uint) error {
+	switch {
+	case r.Status == ReportResolved:
+		return ErrReportResolved
+	case r.Status == ReportClaimed && r.ClaimedBy != modID:
+		return ErrReportClaimed
+	}
+	return nil
+}
+
+//update applies fields to the report if the given mod can handle it,
+//checking that in the same query so two mods can't both handle it
+func (r *Report) update(modID uint, fields map[string]interface{}) error {
+	rows := db.DB.Model(&Report{}).
+		Where("id = ? AND (status = ? OR (status = ? AND claimed_by = ?))", r.ID, ReportOpen, ReportClaimed, modID).
+		UpdateColumns(fields).RowsAffected
+	if rows == 0 {
+		// the report changed since it was loaded
+		db.DB.First(r, r.ID)
+		if err := r.CanHandle(modID); err != nil {
+			return err
+		}
+		return ErrReportClaimed
+	}
+
+	return db.DB.First(r, r.ID).Error
+}
+
+//Claim marks the report as being handled by the given mod
+func (r *Report) Claim(modID uint) error {
+	return r.update(modID, map[string]interface{}{
+		"status":     ReportClaimed,
+		"claimed_by": modID,
+	})
+}
+
+//Resolve closes the report, outcome is the message sent to the reporter,
+//and banID the ban given to the reported player (0 if none)
+func (r *Report) Resolve(modID uint, outcome string, banID uint) error {
+	return r.update(modID, map[string]interface{}{
+		"status":      ReportResolved,
+		"claimed_by":  modID,
+		"outcome":     outcome,
+		"ban_id":      banID,
+		"resolved_at": time.Now(),
+	})
+}
+
+//SetBan links the ban given to the reported player to a resolved report
+func (r *Report) SetBan(banID uint) error {
+	r.BanID = banID
+	return db.DB.Model(r).UpdateColumn("ban_id", banID).Error
 }
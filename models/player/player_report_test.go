@@ -48,3 +48,74 @@ func TestReportVoted(t *testing.T) {
 	assert.True(t, banned, "Player should be banned from joining lobbies")
 	assert.WithinDuration(t, until, time.Now(), 30*time.Minute)
 }
+
+func TestFileReport(t *testing.T) {
+	t.Parallel()
+	reporter := testhelpers.CreatePlayer()
+	target := testhelpers.CreatePlayer()
+
+	_, err := reporter.FileReport(reporter, Toxicity, 0, nil, "")
+	assert.Equal(t, ErrReportSelf, err)
+	_, err = reporter.FileReport(target, ReportCategory("rude"), 0, nil, "")
+	assert.Equal(t, ErrReportCategory, err)
+
+	report, err := reporter.FileReport(target, Toxicity, 0, []uint{4, 2}, "flamed the medic")
+	assert.NoError(t, err)
+	assert.Equal(t, ReportOpen, report.Status)
+	assert.Equal(t, []uint{4, 2}, report.ChatIDList())
+
+	_, err = reporter.FileReport(target, Griefing, 0, nil, "")
+	assert.Equal(t, ErrReportDupe, err)
+
+	// filed reports don't count towards automatic bans
+	assert.False(t, target.IsBanned(BanJoin))
+
+	assert.NoError(t, report.Claim(1))
+	assert.Equal(t, ErrReportClaimed, report.Claim(2))
+	assert.Equal(t, ErrReportClaimed, report.Resolve(2, "", 0))
+	assert.NoError(t, report.Resolve(1, "Player was warned", 0))
+	assert.Equal(t, ErrReportResolved, report.Claim(1))
+
+	report, err = GetReport(report.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, ReportResolved, report.Status)
+	assert.Equal(t, "Player was warned", report.Outcome)
+	assert.NotNil(t, report.ResolvedAt)
+	assert.Len(t, reporter.GetReportsFiled(), 1)
+	assert.Len(t, target.GetReportsAgainst(), 1)
+}
+
+func TestAutoBanEscalation(t *testing.T) {
+	t.Parallel()
+	p := testhelpers.CreatePlayer()
+
+	p.NewReport(RageQuit, 1)
+	assert.False(t, p.IsBanned(BanJoin))
+	p.NewReport(RageQuit, 2)
+	ban, err := p.GetActiveBan(BanJoin)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(30*time.Minute), ban.Until, time.Minute)
+	assert.Contains(t, ban.Reason, `rule "ragequits"`)
+
+	// reports that led to the first ban don't count again
+	p.Unban(BanJoin)
+	p.NewReport(RageQuit, 3)
+	assert.False(t, p.IsBanned(BanJoin))
+
+	p.NewReport(RageQuit, 4)
+	ban, err = p.GetActiveBan(BanJoin)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(2*time.Hour), ban.Until, time.Minute)
+	assert.Contains(t, ban.Reason, "automatic ban #2")
+}
+
+func TestAutoBanExempt(t *testing.T) {
+	t.Parallel()
+	p := testhelpers.CreatePlayer()
+	p.AutoBanExempt = true
+	p.Save()
+
+	p.NewReport(Substitute, 1)
+	p.NewReport(Substitute, 2)
+	assert.False(t, p.IsBanned(BanJoin))
+}
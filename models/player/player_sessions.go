@@ -0,0 +1,119 @@
+package player
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/TF2Stadium/Helen/config"
+	"github.com/TF2Stadium/Helen/controllers/broadcaster"
+	db "github.com/TF2Stadium/Helen/database"
+	"github.com/jinzhu/gorm"
+)
+
+var ErrSessionInvalid = errors.New("Session expired or revoked, please log in again")
+
+//Session is a login session, created when a player logs in through Steam.
+//Access tokens (JWTs) are short lived, and are refreshed with the session's
+//refresh token, which is only stored hashed.
+type Session struct {
+	ID         uint      `json:"id"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+
+	PlayerID    uint   `json:"-" sql:"index"`
+	RefreshHash string `json:"-" sql:"not null;unique"`
+	// incremented when the player's role or bans change, access tokens
+	// issued with an older generation are no longer valid
+	Generation int  `json:"-"`
+	Revoked    bool `json:"-"`
+
+	UserAgent string `json:"userAgent"`
+	IPAddr    string `json:"ip"`
+}
+
+func (Session) TableName() string { return "player_sessions" }
+
+func hashRefreshToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+//NewSession creates a login session for the player, and returns it with its refresh token
+func (player *Player) NewSession(userAgent, ipaddr string) (*Session, string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return nil, "", err
+	}
+	raw := hex.EncodeToString(bytes)
+
+	now := time.Now()
+	session := &Session{
+		LastUsedAt:  now,
+		ExpiresAt:   now.Add(config.Constants.SessionLifetime),
+		PlayerID:    player.ID,
+		RefreshHash: hashRefreshToken(raw),
+		UserAgent:   userAgent,
+		IPAddr:      ipaddr,
+	}
+
+	err := db.DB.Create(session).Error
+	return session, raw, err
+}
+
+//GetSessionByRefreshToken returns the active session with the given refresh token
+func GetSessionByRefreshToken(raw string) (*Session, error) {
+	session := &Session{}
+	err := db.DB.Where("refresh_hash = ? AND revoked = FALSE AND expires_at > now()", hashRefreshToken(raw)).First(session).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrSessionInvalid
+	}
+	return session, err
+}
+
+//IsSessionValid returns true if the session is active, and its generation hasn't changed
+func IsSessionValid(id, playerID uint, generation int) bool {
+	var count int
+	db.DB.Model(&Session{}).Where("id = ? AND player_id = ? AND generation = ? AND revoked = FALSE AND expires_at > now()", id, playerID, generation).Count(&count)
+	return count != 0
+}
+
+//Touch updates the time the session was last used at
+func (s *Session) Touch() {
+	s.LastUsedAt = time.Now()
+	db.DB.Model(&Session{}).Where("id = ?", s.ID).UpdateColumn("last_used_at", s.LastUsedAt)
+}
+
+//GetSessions returns the player's active sessions
+func (player *Player) GetSessions() []*Session {
+	sessions := []*Session{}
+	db.DB.Where("player_id = ? AND revoked = FALSE AND expires_at > now()", player.ID).Order("last_used_at desc").Find(&sessions)
+	return sessions
+}
+
+//RevokeSession revokes the player's session with the given ID
+func (player *Player) RevokeSession(id uint) error {
+	rows := db.DB.Model(&Session{}).Where("id = ? AND player_id = ? AND revoked = FALSE", id, player.ID).
+		UpdateColumn("revoked", true).RowsAffected
+	if rows == 0 {
+		return errors.New("No such session")
+	}
+	return nil
+}
+
+//RevokeSessions revokes all of the player's sessions, logging them out everywhere
+func (player *Player) RevokeSessions() {
+	db.DB.Model(&Session{}).Where("player_id = ? AND revoked = FALSE", player.ID).UpdateColumn("revoked", true)
+}
+
+//InvalidateSessions makes the player's current access tokens invalid,
+//so they're refreshed with the player's current role and bans. Sockets
+//logged in with them are closed, since they keep the token they connected with.
+func (player *Player) InvalidateSessions() {
+	db.DB.Model(&Session{}).Where("player_id = ? AND revoked = FALSE", player.ID).
+		UpdateColumn("generation", gorm.Expr("generation + 1"))
+	broadcaster.CloseSockets(player.SteamID)
+}
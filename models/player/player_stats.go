@@ -42,6 +42,10 @@ type PlayerStats struct {
 	SpyHours      time.Duration `json:"spyHours"`
 
 	Substitutes int `json:"substitutes"`
+
+	// lobbies won/lost, only counts lobbies whose logs were uploaded
+	Wins   int `json:"wins"`
+	Losses int `json:"losses"`
 }
 
 func NewStats() PlayerStats {
@@ -209,3 +209,26 @@ func TestGetLobbyID(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, id, lobby.ID)
 }
+
+func TestSessions(t *testing.T) {
+	t.Parallel()
+	player := testhelpers.CreatePlayer()
+
+	session, refresh, err := player.NewSession("test", "127.0.0.1")
+	assert.NoError(t, err)
+
+	session2, err := GetSessionByRefreshToken(refresh)
+	assert.NoError(t, err)
+	assert.Equal(t, session.ID, session2.ID)
+	assert.True(t, IsSessionValid(session.ID, player.ID, 0))
+
+	// role/ban changes invalidate access tokens
+	player.InvalidateSessions()
+	assert.False(t, IsSessionValid(session.ID, player.ID, 0))
+	assert.True(t, IsSessionValid(session.ID, player.ID, 1))
+
+	player.RevokeSessions()
+	assert.False(t, IsSessionValid(session.ID, player.ID, 1))
+	_, err = GetSessionByRefreshToken(refresh)
+	assert.Equal(t, ErrSessionInvalid, err)
+}
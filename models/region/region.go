@@ -0,0 +1,185 @@
+// Copyright (C) 2015  TF2Stadium
+// Use of this source code is governed by the GPLv3
+// that can be found in the COPYING file.
+
+//Package region resolves addresses to regions, with admin managed
+//CIDR overrides and custom regions taking precedence over GeoIP.
+package region
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	db "github.com/TF2Stadium/Helen/database"
+	"github.com/TF2Stadium/Helen/helpers"
+)
+
+//Region is a custom region, like "na-east" for splitting North America
+type Region struct {
+	ID   uint   `json:"-"`
+	Code string `json:"code" sql:"not null;unique"`
+	Name string `json:"name"`
+}
+
+//Override maps addresses in CIDR to a region
+type Override struct {
+	ID        uint
+	CreatedAt time.Time
+
+	CIDR       string `sql:"not null;unique"`
+	RegionCode string
+	Note       string
+}
+
+func (Override) TableName() string { return "region_overrides" }
+
+//regions returned by GeoIP, which overrides can use as well
+var builtin = map[string]string{
+	"af": "Africa",
+	"an": "Antarctica",
+	"as": "Asia",
+	"eu": "Europe",
+	"na": "North America",
+	"oc": "Oceania",
+	"sa": "South America",
+	"ru": "Russia",
+}
+
+type override struct {
+	net  *net.IPNet
+	code string
+	name string
+}
+
+var (
+	mu        = new(sync.RWMutex)
+	overrides []override // sorted most specific first
+)
+
+//sortOverrides sorts overrides so that the most specific network
+//(longest prefix) is checked first.
+func sortOverrides(list []override) {
+	sort.SliceStable(list, func(i, j int) bool {
+		a, _ := list[i].net.Mask.Size()
+		b, _ := list[j].net.Mask.Size()
+		return a > b
+	})
+}
+
+//match returns the first override in list containing ip
+func match(list []override, ip net.IP) (override, bool) {
+	for _, o := range list {
+		if o.net.Contains(ip) {
+			return o, true
+		}
+	}
+	return override{}, false
+}
+
+//Name returns the name of the region with the given code, or an empty
+//string if it doesn't exist
+func Name(code string) string {
+	if name, ok := builtin[code]; ok {
+		return name
+	}
+
+	region := &Region{}
+	if db.DB.Where("code = ?", code).First(region).RecordNotFound() {
+		return ""
+	}
+	return region.Name
+}
+
+//Reload loads the overrides from the database, needs to be called
+//after they're changed.
+func Reload() {
+	var rows []*Override
+	db.DB.Find(&rows)
+
+	var list []override
+	for _, row := range rows {
+		_, ipnet, err := net.ParseCIDR(row.CIDR)
+		if err != nil {
+			logrus.Errorf("Invalid region override %s: %v", row.CIDR, err)
+			continue
+		}
+		list = append(list, override{ipnet, row.RegionCode, Name(row.RegionCode)})
+	}
+	sortOverrides(list)
+
+	mu.Lock()
+	overrides = list
+	mu.Unlock()
+}
+
+//GetRegion returns the region code and name for addr, which can be
+//an IP or a hostname, with or without a port.
+func GetRegion(addr string) (string, string) {
+	host := strings.Split(addr, ":")[0]
+	ipaddr, err := net.ResolveIPAddr("ip4", host)
+	if err == nil {
+		mu.RLock()
+		o, ok := match(overrides, ipaddr.IP)
+		mu.RUnlock()
+		if ok {
+			return o.code, o.name
+		}
+	}
+
+	return helpers.GetRegion(addr)
+}
+
+//AddRegion adds a custom region
+func AddRegion(code, name string) error {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if code == "" || name == "" {
+		return errors.New("Region code and name cannot be empty")
+	}
+	if _, ok := builtin[code]; ok {
+		return errors.New("Region code is already used by GeoIP")
+	}
+
+	return db.DB.Create(&Region{Code: code, Name: name}).Error
+}
+
+//AddOverride adds an override for the given network, and reloads overrides
+func AddOverride(cidr, code, note string) error {
+	_, ipnet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+	if err != nil {
+		return err
+	}
+	if Name(code) == "" {
+		return errors.New("No region with code " + code)
+	}
+
+	err = db.DB.Create(&Override{CIDR: ipnet.String(), RegionCode: code, Note: note}).Error
+	if err != nil {
+		return err
+	}
+
+	Reload()
+	return nil
+}
+
+//RemoveOverride removes the override for the given network, and reloads overrides
+func RemoveOverride(cidr string) {
+	db.DB.Where("cidr = ?", cidr).Delete(&Override{})
+	Reload()
+}
+
+//GetAllRegions returns all custom regions
+func GetAllRegions() (regions []*Region) {
+	db.DB.Order("code").Find(&regions)
+	return
+}
+
+//GetAllOverrides returns all overrides
+func GetAllOverrides() (list []*Override) {
+	db.DB.Order("id").Find(&list)
+	return
+}
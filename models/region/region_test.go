@@ -0,0 +1,37 @@
+package region
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustOverride(cidr, code string) override {
+	_, ipnet, _ := net.ParseCIDR(cidr)
+	return override{ipnet, code, code}
+}
+
+func TestMatchMostSpecific(t *testing.T) {
+	list := []override{
+		mustOverride("10.0.0.0/8", "eu"),
+		mustOverride("10.1.0.0/16", "na-east"),
+		mustOverride("10.1.2.0/24", "na-west"),
+	}
+	sortOverrides(list)
+
+	o, ok := match(list, net.ParseIP("10.1.2.3"))
+	assert.True(t, ok)
+	assert.Equal(t, "na-west", o.code)
+
+	o, ok = match(list, net.ParseIP("10.1.3.3"))
+	assert.True(t, ok)
+	assert.Equal(t, "na-east", o.code)
+
+	o, ok = match(list, net.ParseIP("10.2.0.1"))
+	assert.True(t, ok)
+	assert.Equal(t, "eu", o.code)
+
+	_, ok = match(list, net.ParseIP("192.168.0.1"))
+	assert.False(t, ok)
+}
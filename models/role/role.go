@@ -0,0 +1,257 @@
+// Copyright (C) 2015  TF2Stadium
+// Use of this source code is governed by the GPLv3
+// that can be found in the COPYING file.
+
+//Package role stores roles and the actions they're allowed to do, which
+//can be edited by admins at /admin/roles/edit
+package role
+
+import (
+	"errors"
+	"strings"
+
+	db "github.com/TF2Stadium/Helen/database"
+	"github.com/TF2Stadium/Helen/helpers"
+	"github.com/TF2Stadium/Helen/helpers/authority"
+	"github.com/sirupsen/logrus"
+)
+
+//Role is a role players can have
+type Role struct {
+	ID     uint
+	Number authority.AuthRole `sql:"not null;unique"` // value of Player.Role for players with this role
+	Name   string             `sql:"not null;unique"`
+	Parent string             // name of the role this one inherits actions from, empty if none
+
+	Actions []string `sql:"-"` // actions granted directly to this role
+}
+
+//Grant allows a role to do an action
+type Grant struct {
+	ID     uint
+	RoleID uint   `sql:"index"`
+	Action string // one of helpers.ActionNames
+}
+
+func (Grant) TableName() string { return "role_grants" }
+
+var (
+	ErrNoRole       = errors.New("No such role")
+	ErrNoAction     = errors.New("No such action")
+	ErrBuiltinRole  = errors.New("Built-in roles can't be removed")
+	ErrInheritCycle = errors.New("A role can't inherit from itself")
+)
+
+var actionsByName = make(map[string]authority.AuthAction)
+
+func init() {
+	for action, name := range helpers.ActionNames {
+		actionsByName[name] = action
+	}
+}
+
+//build computes the actions every role can do, including inherited ones
+func build(roles []*Role) (map[authority.AuthRole]map[authority.AuthAction]bool, map[authority.AuthRole]string) {
+	byName := make(map[string]*Role)
+	for _, role := range roles {
+		byName[role.Name] = role
+	}
+
+	perms := make(map[authority.AuthRole]map[authority.AuthAction]bool)
+	names := make(map[authority.AuthRole]string)
+
+	for _, role := range roles {
+		names[role.Number] = role.Name
+		actions := make(map[authority.AuthAction]bool)
+
+		// walk up the parents, seen stops cycles
+		seen := make(map[string]bool)
+		for cur := role; cur != nil && !seen[cur.Name]; cur = byName[cur.Parent] {
+			seen[cur.Name] = true
+			for _, name := range cur.Actions {
+				if action, ok := actionsByName[name]; ok {
+					actions[action] = true
+				}
+			}
+		}
+
+		perms[role.Number] = actions
+	}
+
+	return perms, names
+}
+
+func getRoles() []*Role {
+	var roles []*Role
+	db.DB.Order("number").Find(&roles)
+
+	var grants []*Grant
+	db.DB.Order("id").Find(&grants)
+
+	byID := make(map[uint]*Role)
+	for _, role := range roles {
+		byID[role.ID] = role
+	}
+	for _, grant := range grants {
+		if role, ok := byID[grant.RoleID]; ok {
+			role.Actions = append(role.Actions, grant.Action)
+		}
+	}
+
+	return roles
+}
+
+//createDefaults creates the built-in roles which aren't in the database yet
+func createDefaults() {
+	for _, def := range helpers.DefaultRoles {
+		var count int
+		db.DB.Model(&Role{}).Where("number = ?", def.Role).Count(&count)
+		if count != 0 {
+			continue
+		}
+
+		role := &Role{Number: def.Role, Name: helpers.RoleNames[def.Role]}
+		if def.Parent != -1 {
+			role.Parent = helpers.RoleNames[def.Parent]
+		}
+		if err := db.DB.Create(role).Error; err != nil {
+			logrus.Error(err)
+			continue
+		}
+
+		for _, action := range def.Actions {
+			db.DB.Create(&Grant{RoleID: role.ID, Action: helpers.ActionNames[action]})
+		}
+	}
+}
+
+//Reload loads roles and their actions from the database, needs to be called
+//after they're changed
+func Reload() {
+	createDefaults()
+	authority.Load(build(getRoles()))
+}
+
+//GetAllRoles returns all roles, with the actions granted to each
+func GetAllRoles() []*Role {
+	return getRoles()
+}
+
+func getRole(name string) (*Role, error) {
+	role := &Role{}
+	if db.DB.Where("name = ?", name).First(role).RecordNotFound() {
+		return nil, ErrNoRole
+	}
+	return role, nil
+}
+
+//GetRole returns the role with the given name
+func GetRole(name string) (authority.AuthRole, error) {
+	role, err := getRole(name)
+	if err != nil {
+		return 0, err
+	}
+	return role.Number, nil
+}
+
+//AddRole adds a role, which inherits from parent (if not empty)
+func AddRole(name, parent string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("Role name cannot be empty")
+	}
+	if parent != "" {
+		if _, err := getRole(parent); err != nil {
+			return err
+		}
+	}
+
+	var max struct{ Number int }
+	db.DB.Model(&Role{}).Select("MAX(number) AS number").Scan(&max)
+
+	err := db.DB.Create(&Role{Number: authority.AuthRole(max.Number + 1), Name: name, Parent: parent}).Error
+	if err != nil {
+		return err
+	}
+
+	Reload()
+	return nil
+}
+
+//SetParent makes the role inherit actions from parent, or nothing if parent is empty
+func SetParent(name, parent string) error {
+	role, err := getRole(name)
+	if err != nil {
+		return err
+	}
+
+	// check parent doesn't (eventually) inherit from role
+	for cur := parent; cur != ""; {
+		if cur == name {
+			return ErrInheritCycle
+		}
+		p, err := getRole(cur)
+		if err != nil {
+			return err
+		}
+		cur = p.Parent
+	}
+
+	db.DB.Model(role).UpdateColumn("parent", parent)
+	Reload()
+	return nil
+}
+
+//Allow grants the action to the role
+func Allow(name, action string) error {
+	role, err := getRole(name)
+	if err != nil {
+		return err
+	}
+	if _, ok := actionsByName[action]; !ok {
+		return ErrNoAction
+	}
+
+	var count int
+	db.DB.Model(&Grant{}).Where("role_id = ? AND action = ?", role.ID, action).Count(&count)
+	if count == 0 {
+		db.DB.Create(&Grant{RoleID: role.ID, Action: action})
+	}
+
+	Reload()
+	return nil
+}
+
+//Disallow removes the action from the role's grants. The role can still do
+//the action if it inherits it.
+func Disallow(name, action string) error {
+	role, err := getRole(name)
+	if err != nil {
+		return err
+	}
+
+	db.DB.Where("role_id = ? AND action = ?", role.ID, action).Delete(&Grant{})
+	Reload()
+	return nil
+}
+
+//RemoveRole removes a role which isn't built-in. Players with the role
+//become normal players.
+func RemoveRole(name string) error {
+	role, err := getRole(name)
+	if err != nil {
+		return err
+	}
+	if _, ok := helpers.RoleNames[role.Number]; ok {
+		return ErrBuiltinRole
+	}
+
+	db.DB.Exec("UPDATE player_sessions SET generation = generation + 1 WHERE player_id IN (SELECT id FROM players WHERE role = ?)", role.Number)
+	db.DB.Exec("UPDATE players SET role = ? WHERE role = ?", helpers.RolePlayer, role.Number)
+	db.DB.Model(&Role{}).Where("parent = ?", role.Name).UpdateColumn("parent", "")
+	db.DB.Where("role_id = ?", role.ID).Delete(&Grant{})
+	db.DB.Delete(role)
+
+	Reload()
+	return nil
+}
@@ -0,0 +1,39 @@
+package role
+
+import (
+	"testing"
+
+	"github.com/TF2Stadium/Helen/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildInheritance(t *testing.T) {
+	roles := []*Role{
+		{Number: 0, Name: "player"},
+		{Number: 1, Name: "moderator", Parent: "player", Actions: []string{"ActionBanChat", "ActionCloseLobby"}},
+		{Number: 2, Name: "administrator", Parent: "moderator", Actions: []string{"ActionChangeRole"}},
+		{Number: 4, Name: "lobby moderator", Parent: "player", Actions: []string{"ActionCloseLobby"}},
+	}
+
+	perms, names := build(roles)
+	assert.Equal(t, "lobby moderator", names[4])
+
+	assert.True(t, perms[2][helpers.ActionChangeRole])
+	assert.True(t, perms[2][helpers.ActionBanChat])
+	assert.False(t, perms[1][helpers.ActionChangeRole])
+
+	assert.True(t, perms[4][helpers.ActionCloseLobby])
+	assert.False(t, perms[4][helpers.ActionBanChat])
+	assert.Empty(t, perms[0])
+}
+
+func TestBuildCycle(t *testing.T) {
+	roles := []*Role{
+		{Number: 5, Name: "a", Parent: "b", Actions: []string{"ActionBanChat"}},
+		{Number: 6, Name: "b", Parent: "a", Actions: []string{"ActionCloseLobby"}},
+	}
+
+	perms, _ := build(roles)
+	assert.True(t, perms[5][helpers.ActionCloseLobby])
+	assert.True(t, perms[6][helpers.ActionBanChat])
+}
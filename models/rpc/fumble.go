@@ -7,7 +7,7 @@ func FumbleLobbyCreated(lobbyID uint) error {
 		return nil
 	}
 
-	err := fumble.Call("Fumble.CreateLobby", lobbyID, &struct{}{})
+	err := fumble.CreateLobby(lobbyID)
 
 	if err != nil {
 		logrus.Error(err)
@@ -22,7 +22,7 @@ func FumbleLobbyEnded(lobbyID uint) {
 		return
 	}
 
-	err := fumble.Call("Fumble.EndLobby", lobbyID, &struct{}{})
+	err := fumble.EndLobby(lobbyID)
 	if err != nil {
 		logrus.Error(err)
 	}
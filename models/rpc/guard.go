@@ -0,0 +1,182 @@
+// Copyright (C) 2015  TF2Stadium
+// Use of this source code is governed by the GPLv3
+// that can be found in the COPYING file.
+
+package rpc
+
+import (
+	"errors"
+	"expvar"
+	"net/rpc"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrPaulingUnavailable = errors.New("The game server manager is unavailable right now, please try again in a minute.")
+	ErrPaulingTimeout     = errors.New("The game server manager took too long to respond, please try again.")
+)
+
+//callPolicy decides how long a call can take, and how many times it's
+//retried after failing. Only calls which can be safely repeated are retried.
+type callPolicy struct {
+	timeout time.Duration
+	retries int
+}
+
+var paulingPolicies = map[string]callPolicy{
+	"Pauling.SetupServer":    {30 * time.Second, 0},
+	"Pauling.VerifyInfo":     {15 * time.Second, 1},
+	"Pauling.ReExecConfig":   {10 * time.Second, 2},
+	"Pauling.End":            {10 * time.Second, 2},
+	"Pauling.Say":            {5 * time.Second, 0},
+	"Pauling.DisallowPlayer": {5 * time.Second, 2},
+	"Pauling.Exists":         {5 * time.Second, 2},
+}
+
+var defaultPolicy = callPolicy{10 * time.Second, 0}
+
+//time to wait before retrying, multiplied by the attempt number
+var retryBackoff = 250 * time.Millisecond
+
+//paulingStats has the number of calls, failures, timeouts and the total
+//latency (in milliseconds) for every Pauling method, served over /debug/vars
+var paulingStats = expvar.NewMap("pauling")
+
+//breaker is a circuit breaker. After threshold consecutive failures it
+//opens, and calls fail immediately until cooldown has passed. After
+//that a single call is let through, which closes the breaker if it succeeds.
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+
+	failures int
+	openedAt time.Time
+	trial    bool // true if a call is being let through an open breaker
+}
+
+var paulingBreaker = &breaker{threshold: 5, cooldown: 30 * time.Second}
+
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+
+	if b.trial || time.Since(b.openedAt) < b.cooldown {
+		return false
+	}
+
+	b.trial = true
+	return true
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	b.failures = 0
+	b.trial = false
+	b.mu.Unlock()
+}
+
+func (b *breaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.failures >= b.threshold {
+		if b.trial || b.failures == b.threshold {
+			logrus.Warning("Too many failed calls to Pauling, failing calls for ", b.cooldown)
+		}
+		b.openedAt = time.Now()
+	}
+	b.trial = false
+}
+
+func (b *breaker) open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures >= b.threshold
+}
+
+func (b *breaker) reset() {
+	b.mu.Lock()
+	b.failures = 0
+	b.trial = false
+	b.mu.Unlock()
+}
+
+func callWithTimeout(timeout time.Duration, f func() error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- f()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return ErrPaulingTimeout
+	}
+}
+
+//callPauling makes a call to Pauling with f, applying the deadline and
+//retries for method, and going through the circuit breaker.
+//Errors returned by Pauling itself (like a wrong RCON password) are
+//returned as they are, and don't count as failures.
+func callPauling(method string, f func(PaulingClient) error) error {
+	policy, ok := paulingPolicies[method]
+	if !ok {
+		policy = defaultPolicy
+	}
+
+	client := pauling
+
+	var err error
+	for attempt := 0; attempt <= policy.retries; attempt++ {
+		if attempt != 0 {
+			time.Sleep(time.Duration(attempt) * retryBackoff)
+		}
+
+		if !paulingBreaker.allow() {
+			paulingStats.Add("rejected", 1)
+			return ErrPaulingUnavailable
+		}
+
+		start := time.Now()
+		err = callWithTimeout(policy.timeout, func() error {
+			return f(client)
+		})
+
+		paulingStats.Add(method+".calls", 1)
+		paulingStats.Add(method+".latency_ms", int64(time.Since(start)/time.Millisecond))
+
+		if err == nil {
+			paulingBreaker.success()
+			return nil
+		}
+
+		if _, ok := err.(rpc.ServerError); ok {
+			paulingBreaker.success()
+			return err
+		}
+
+		paulingStats.Add(method+".failures", 1)
+		if err == ErrPaulingTimeout {
+			paulingStats.Add(method+".timeouts", 1)
+		}
+		paulingBreaker.failure()
+		logrus.Errorf("%s failed (attempt %d): %v", method, attempt+1, err)
+	}
+
+	return err
+}
+
+//PaulingAvailable returns false if calls to Pauling are currently failing fast
+func PaulingAvailable() bool {
+	return !paulingBreaker.open()
+}
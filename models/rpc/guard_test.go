@@ -0,0 +1,96 @@
+package rpc
+
+import (
+	"errors"
+	"net/rpc"
+	"testing"
+	"time"
+
+	"github.com/TF2Stadium/Helen/models/gameserver"
+	"github.com/TF2Stadium/Helen/models/lobby/format"
+	"github.com/stretchr/testify/assert"
+)
+
+type slowPauling struct {
+	*Memory
+	delay time.Duration
+}
+
+func (p slowPauling) SetupServer(args *Args) error {
+	time.Sleep(p.delay)
+	return p.Memory.SetupServer(args)
+}
+
+func setup() *Memory {
+	paulingBreaker.reset()
+	retryBackoff = time.Millisecond
+
+	m := NewMemory()
+	UseMemory(m)
+	return m
+}
+
+func TestRetryIdempotent(t *testing.T) {
+	m := setup()
+	m.Fail("Pauling.ReExecConfig", errors.New("connection reset"))
+
+	err := ReExecConfig(1, false)
+	assert.Error(t, err)
+	assert.Len(t, m.CallsTo("Pauling.ReExecConfig"), 1+paulingPolicies["Pauling.ReExecConfig"].retries)
+}
+
+func TestNoRetrySetupServer(t *testing.T) {
+	m := setup()
+	m.Fail("Pauling.SetupServer", errors.New("connection reset"))
+
+	err := SetupServer(1, gameserver.ServerRecord{}, format.Sixes, "etf2l", "", "cp_badlands")
+	assert.Error(t, err)
+	assert.Len(t, m.CallsTo("Pauling.SetupServer"), 1)
+}
+
+func TestServerErrorNotRetried(t *testing.T) {
+	m := setup()
+	m.Fail("Pauling.VerifyInfo", rpc.ServerError("wrong rcon password"))
+
+	for i := 0; i < 2*paulingBreaker.threshold; i++ {
+		assert.EqualError(t, VerifyInfo(gameserver.ServerRecord{}), "wrong rcon password")
+	}
+	assert.Len(t, m.CallsTo("Pauling.VerifyInfo"), 2*paulingBreaker.threshold)
+	assert.True(t, PaulingAvailable())
+}
+
+func TestTimeout(t *testing.T) {
+	m := setup()
+	Use(slowPauling{m, 50 * time.Millisecond}, m, m)
+
+	old := paulingPolicies["Pauling.SetupServer"]
+	paulingPolicies["Pauling.SetupServer"] = callPolicy{10 * time.Millisecond, 0}
+	defer func() { paulingPolicies["Pauling.SetupServer"] = old }()
+
+	err := SetupServer(1, gameserver.ServerRecord{}, format.Sixes, "etf2l", "", "cp_badlands")
+	assert.Equal(t, ErrPaulingTimeout, err)
+}
+
+func TestBreaker(t *testing.T) {
+	m := setup()
+	m.Fail("Pauling.SetupServer", errors.New("connection refused"))
+
+	for i := 0; i < paulingBreaker.threshold; i++ {
+		SetupServer(1, gameserver.ServerRecord{}, format.Sixes, "etf2l", "", "cp_badlands")
+	}
+	assert.False(t, PaulingAvailable())
+
+	err := SetupServer(1, gameserver.ServerRecord{}, format.Sixes, "etf2l", "", "cp_badlands")
+	assert.Equal(t, ErrPaulingUnavailable, err)
+	assert.Len(t, m.CallsTo("Pauling.SetupServer"), paulingBreaker.threshold)
+
+	// after the cooldown, a successful call closes the breaker
+	m.Fail("Pauling.SetupServer", nil)
+	paulingBreaker.mu.Lock()
+	paulingBreaker.openedAt = time.Now().Add(-paulingBreaker.cooldown)
+	paulingBreaker.mu.Unlock()
+
+	err = SetupServer(1, gameserver.ServerRecord{}, format.Sixes, "etf2l", "", "cp_badlands")
+	assert.NoError(t, err)
+	assert.True(t, PaulingAvailable())
+}
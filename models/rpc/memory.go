@@ -0,0 +1,165 @@
+// Copyright (C) 2015  TF2Stadium
+// Use of this source code is governed by the GPLv3
+// that can be found in the COPYING file.
+
+package rpc
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/TF2Stadium/Helen/models/gameserver"
+)
+
+//Call is a single call recorded by Memory
+type Call struct {
+	Method string // Name of the method, like "Pauling.SetupServer"
+	Args   interface{}
+}
+
+//Memory is an in-process implementation of the Pauling, Fumble and
+//Twitch bot clients. It doesn't talk to any service, it only records
+//the calls made to it, so lobbies can be run without RabbitMQ.
+type Memory struct {
+	mu      sync.Mutex
+	calls   []Call
+	errors  map[string]error
+	servers map[uint]bool
+}
+
+//NewMemory returns a new in-process client
+func NewMemory() *Memory {
+	return &Memory{
+		errors:  make(map[string]error),
+		servers: make(map[uint]bool),
+	}
+}
+
+//UseMemory makes m the client for all RPC calls
+func UseMemory(m *Memory) {
+	Use(m, m, m)
+}
+
+func (m *Memory) record(method string, args interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, Call{method, args})
+	return m.errors[method]
+}
+
+//Fail makes all future calls to method return err. A nil err
+//makes the calls succeed again.
+func (m *Memory) Fail(method string, err error) {
+	m.mu.Lock()
+	if err == nil {
+		delete(m.errors, method)
+	} else {
+		m.errors[method] = err
+	}
+	m.mu.Unlock()
+}
+
+//Calls returns all the calls made so far, in order
+func (m *Memory) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	calls := make([]Call, len(m.calls))
+	copy(calls, m.calls)
+	return calls
+}
+
+//CallsTo returns the calls made to the given method. If method doesn't
+//have a service prefix ("Pauling."), calls to all services are matched.
+func (m *Memory) CallsTo(method string) []Call {
+	var calls []Call
+
+	for _, call := range m.Calls() {
+		if call.Method == method || strings.HasSuffix(call.Method, "."+method) {
+			calls = append(calls, call)
+		}
+	}
+
+	return calls
+}
+
+//Reset forgets all recorded calls, errors and running servers
+func (m *Memory) Reset() {
+	m.mu.Lock()
+	m.calls = nil
+	m.errors = make(map[string]error)
+	m.servers = make(map[uint]bool)
+	m.mu.Unlock()
+}
+
+func (m *Memory) SetupServer(args *Args) error {
+	err := m.record("Pauling.SetupServer", *args)
+	if err == nil {
+		m.mu.Lock()
+		m.servers[args.Id] = true
+		m.mu.Unlock()
+	}
+
+	return err
+}
+
+func (m *Memory) ReExecConfig(args *Args) error {
+	return m.record("Pauling.ReExecConfig", *args)
+}
+
+func (m *Memory) VerifyInfo(info *gameserver.ServerRecord) error {
+	return m.record("Pauling.VerifyInfo", *info)
+}
+
+func (m *Memory) End(args *Args) error {
+	err := m.record("Pauling.End", *args)
+
+	m.mu.Lock()
+	delete(m.servers, args.Id)
+	m.mu.Unlock()
+	return err
+}
+
+func (m *Memory) Say(args *Args) error {
+	return m.record("Pauling.Say", *args)
+}
+
+func (m *Memory) DisallowPlayer(args *Args) error {
+	return m.record("Pauling.DisallowPlayer", *args)
+}
+
+func (m *Memory) Exists(lobbyID uint) (bool, error) {
+	err := m.record("Pauling.Exists", lobbyID)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.servers[lobbyID], err
+}
+
+func (m *Memory) CreateLobby(lobbyID uint) error {
+	return m.record("Fumble.CreateLobby", lobbyID)
+}
+
+func (m *Memory) EndLobby(lobbyID uint) error {
+	return m.record("Fumble.EndLobby", lobbyID)
+}
+
+func (m *Memory) RemovePlayer(playerID uint) error {
+	return m.record("Fumble.RemovePlayer", playerID)
+}
+
+func (m *Memory) Join(channel string) error {
+	return m.record("TwitchBot.Join", channel)
+}
+
+func (m *Memory) Leave(channel string) error {
+	return m.record("TwitchBot.Leave", channel)
+}
+
+func (m *Memory) Announce(channel string, lobbyID uint) {
+	m.record("TwitchBot.Announce", struct {
+		Channel string
+		LobbyID uint
+	}{channel, lobbyID})
+}
@@ -25,11 +25,13 @@ type Args struct {
 
 func DisallowPlayer(lobbyId uint, steamId string, playerID uint) error {
 	if !*paulingDisabled {
-		pauling.Call("Pauling.DisallowPlayer", &Args{Id: lobbyId, SteamId: steamId}, &struct{}{})
+		callPauling("Pauling.DisallowPlayer", func(pauling PaulingClient) error {
+			return pauling.DisallowPlayer(&Args{Id: lobbyId, SteamId: steamId})
+		})
 	}
 
 	if !*fumbleDisabled {
-		fumble.Call("Fumble.RemovePlayer", playerID, &struct{}{})
+		fumble.RemovePlayer(playerID)
 	}
 
 	return nil
@@ -48,41 +50,66 @@ func SetupServer(lobbyId uint, info gameserver.ServerRecord, lobbyType format.Fo
 		League:    league,
 		Whitelist: whitelist,
 		Map:       mapName}
-	return pauling.Call("Pauling.SetupServer", args, &struct{}{})
+	return callPauling("Pauling.SetupServer", func(pauling PaulingClient) error {
+		return pauling.SetupServer(args)
+	})
 }
 
 func ReExecConfig(lobbyId uint, changeMap bool) error {
 	if *paulingDisabled {
 		return nil
 	}
-	return pauling.Call("Pauling.ReExecConfig", &Args{Id: lobbyId, ChangeMap: changeMap}, &struct{}{})
+	return callPauling("Pauling.ReExecConfig", func(pauling PaulingClient) error {
+		return pauling.ReExecConfig(&Args{Id: lobbyId, ChangeMap: changeMap})
+	})
 }
 
 func VerifyInfo(info gameserver.ServerRecord) error {
 	if *paulingDisabled {
 		return nil
 	}
-	return pauling.Call("Pauling.VerifyInfo", &info, &struct{}{})
+	return callPauling("Pauling.VerifyInfo", func(pauling PaulingClient) error {
+		return pauling.VerifyInfo(&info)
+	})
 }
 
 func End(lobbyId uint) {
 	if *paulingDisabled {
 		return
 	}
-	pauling.Call("Pauling.End", &Args{Id: lobbyId}, &struct{}{})
+	callPauling("Pauling.End", func(pauling PaulingClient) error {
+		return pauling.End(&Args{Id: lobbyId})
+	})
 }
 
 func Say(lobbyId uint, text string) {
 	if *paulingDisabled {
 		return
 	}
-	pauling.Call("Pauling.Say", &Args{Id: lobbyId, Text: text}, &struct{}{})
+	callPauling("Pauling.Say", func(pauling PaulingClient) error {
+		return pauling.Say(&Args{Id: lobbyId, Text: text})
+	})
 }
 
 func serverExists(lobbyID uint) (exists bool) {
 	if *paulingDisabled {
 		return false
 	}
-	pauling.Call("Pauling.Exists", lobbyID, &exists)
-	return
+	//the call might finish after callPauling has given up on it
+	result := make(chan bool, 1)
+	err := callPauling("Pauling.Exists", func(pauling PaulingClient) error {
+		exists, err := pauling.Exists(lobbyID)
+		if err == nil {
+			select {
+			case result <- exists:
+			default:
+			}
+		}
+		return err
+	})
+	if err != nil {
+		return false
+	}
+
+	return <-result
 }
@@ -10,14 +10,42 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/TF2Stadium/Helen/config"
+	"github.com/TF2Stadium/Helen/models/gameserver"
 	"github.com/streadway/amqp"
 	"github.com/vibhavp/amqp-rpc"
 )
 
+//PaulingClient is the set of calls Helen makes to Pauling,
+//which manages the game servers for lobbies.
+type PaulingClient interface {
+	SetupServer(args *Args) error
+	ReExecConfig(args *Args) error
+	VerifyInfo(info *gameserver.ServerRecord) error
+	End(args *Args) error
+	Say(args *Args) error
+	DisallowPlayer(args *Args) error
+	Exists(lobbyID uint) (bool, error)
+}
+
+//FumbleClient is the set of calls Helen makes to Fumble,
+//which manages the mumble channels for lobbies.
+type FumbleClient interface {
+	CreateLobby(lobbyID uint) error
+	EndLobby(lobbyID uint) error
+	RemovePlayer(playerID uint) error
+}
+
+//TwitchBotClient is the set of calls Helen makes to the Twitch bot.
+type TwitchBotClient interface {
+	Join(channel string) error
+	Leave(channel string) error
+	Announce(channel string, lobbyID uint)
+}
+
 var (
-	pauling   *rpc.Client
-	fumble    *rpc.Client
-	twitchbot *rpc.Client
+	pauling   PaulingClient
+	fumble    FumbleClient
+	twitchbot TwitchBotClient
 
 	paulingDisabled   = flag.Bool("disable_pauling", true, "disable pauling")
 	fumbleDisabled    = flag.Bool("disable_fumble", true, "disable fumble")
@@ -26,27 +54,99 @@ var (
 
 func ConnectRPC(amqpConn *amqp.Connection) {
 	if !*paulingDisabled {
-		codec, err := amqprpc.NewClientCodec(amqpConn, config.Constants.PaulingQueue, amqprpc.JSONCodec{})
-		if err != nil {
-			logrus.Fatal(err)
-		}
-
-		pauling = rpc.NewClientWithCodec(codec)
-
+		pauling = amqpPauling{newAMQPClient(amqpConn, config.Constants.PaulingQueue)}
 	}
 	if !*fumbleDisabled {
-		codec, err := amqprpc.NewClientCodec(amqpConn, config.Constants.FumbleQueue, amqprpc.JSONCodec{})
-		if err != nil {
-			logrus.Fatal(err)
-		}
-
-		fumble = rpc.NewClientWithCodec(codec)
+		fumble = amqpFumble{newAMQPClient(amqpConn, config.Constants.FumbleQueue)}
 	}
 	if !*twitchbotDisabled {
-		codec, err := amqprpc.NewClientCodec(amqpConn, config.Constants.TwitchBotQueue, amqprpc.JSONCodec{})
-		if err != nil {
-			logrus.Fatal(err)
-		}
-		twitchbot = rpc.NewClientWithCodec(codec)
+		twitchbot = amqpTwitchBot{newAMQPClient(amqpConn, config.Constants.TwitchBotQueue)}
+	}
+}
+
+//Use replaces the clients used for making calls to Pauling, Fumble and
+//the Twitch bot. A nil client disables calls to that service.
+func Use(p PaulingClient, f FumbleClient, t TwitchBotClient) {
+	pauling, fumble, twitchbot = p, f, t
+
+	*paulingDisabled = p == nil
+	*fumbleDisabled = f == nil
+	*twitchbotDisabled = t == nil
+}
+
+func newAMQPClient(amqpConn *amqp.Connection, queue string) *rpc.Client {
+	codec, err := amqprpc.NewClientCodec(amqpConn, queue, amqprpc.JSONCodec{})
+	if err != nil {
+		logrus.Fatal(err)
 	}
+
+	return rpc.NewClientWithCodec(codec)
+}
+
+type amqpPauling struct {
+	client *rpc.Client
+}
+
+func (p amqpPauling) SetupServer(args *Args) error {
+	return p.client.Call("Pauling.SetupServer", args, &struct{}{})
+}
+
+func (p amqpPauling) ReExecConfig(args *Args) error {
+	return p.client.Call("Pauling.ReExecConfig", args, &struct{}{})
+}
+
+func (p amqpPauling) VerifyInfo(info *gameserver.ServerRecord) error {
+	return p.client.Call("Pauling.VerifyInfo", info, &struct{}{})
+}
+
+func (p amqpPauling) End(args *Args) error {
+	return p.client.Call("Pauling.End", args, &struct{}{})
+}
+
+func (p amqpPauling) Say(args *Args) error {
+	return p.client.Call("Pauling.Say", args, &struct{}{})
+}
+
+func (p amqpPauling) DisallowPlayer(args *Args) error {
+	return p.client.Call("Pauling.DisallowPlayer", args, &struct{}{})
+}
+
+func (p amqpPauling) Exists(lobbyID uint) (exists bool, err error) {
+	err = p.client.Call("Pauling.Exists", lobbyID, &exists)
+	return
+}
+
+type amqpFumble struct {
+	client *rpc.Client
+}
+
+func (f amqpFumble) CreateLobby(lobbyID uint) error {
+	return f.client.Call("Fumble.CreateLobby", lobbyID, &struct{}{})
+}
+
+func (f amqpFumble) EndLobby(lobbyID uint) error {
+	return f.client.Call("Fumble.EndLobby", lobbyID, &struct{}{})
+}
+
+func (f amqpFumble) RemovePlayer(playerID uint) error {
+	return f.client.Call("Fumble.RemovePlayer", playerID, &struct{}{})
+}
+
+type amqpTwitchBot struct {
+	client *rpc.Client
+}
+
+func (t amqpTwitchBot) Join(channel string) error {
+	return t.client.Call("TwitchBot.Join", channel, &struct{}{})
+}
+
+func (t amqpTwitchBot) Leave(channel string) error {
+	return t.client.Call("TwitchBot.Leave", channel, &struct{}{})
+}
+
+func (t amqpTwitchBot) Announce(channel string, lobbyID uint) {
+	t.client.Go("TwitchBot.Announce", struct {
+		Channel string
+		LobbyID uint
+	}{channel, lobbyID}, &struct{}{}, nil)
 }
@@ -4,14 +4,14 @@ func TwitchBotJoin(channel string) {
 	if *twitchbotDisabled {
 		return
 	}
-	twitchbot.Call("TwitchBot.Join", channel, &struct{}{})
+	twitchbot.Join(channel)
 }
 
 func TwitchBotLeave(channel string) {
 	if *twitchbotDisabled {
 		return
 	}
-	twitchbot.Call("TwitchBot.Leave", channel, &struct{}{})
+	twitchbot.Leave(channel)
 }
 
 func TwitchBotAnnouce(channel string, lobbyid uint) {
@@ -19,8 +19,5 @@ func TwitchBotAnnouce(channel string, lobbyid uint) {
 		return
 	}
 
-	twitchbot.Go("TwitchBot.Announce", struct {
-		Channel string
-		LobbyID uint
-	}{channel, lobbyid}, &struct{}{}, nil)
+	twitchbot.Announce(channel, lobbyid)
 }
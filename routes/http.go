@@ -10,10 +10,12 @@ import (
 	"github.com/TF2Stadium/Helen/config"
 	"github.com/TF2Stadium/Helen/controllers"
 	"github.com/TF2Stadium/Helen/controllers/admin"
+	"github.com/TF2Stadium/Helen/controllers/api"
 	chelpers "github.com/TF2Stadium/Helen/controllers/controllerhelpers"
 	"github.com/TF2Stadium/Helen/controllers/login"
 	"github.com/TF2Stadium/Helen/controllers/stats"
 	"github.com/TF2Stadium/Helen/helpers"
+	"github.com/TF2Stadium/Helen/models/demo"
 )
 
 type route struct {
@@ -26,6 +28,7 @@ var routes = []route{
 	{"/openidcallback", login.SteamLoginCallbackHandler},
 	{"/startLogin", login.SteamLoginHandler},
 	{"/logout", login.SteamLogoutHandler},
+	{"/logoutAll", login.LogoutEverywhereHandler},
 	{"/websocket/", controllers.SocketHandler},
 	{"/startMockLogin", login.SteamMockLoginHandler},
 	{"/startTwitchLogin", login.TwitchLoginHandler},
@@ -33,25 +36,66 @@ var routes = []route{
 	{"/twitchLogout", login.TwitchLogoutHandler},
 
 	{"/admin", chelpers.FilterHTTPRequest(helpers.ActionViewPage, admin.ServeAdminPage)},
-	{"/admin/roles", chelpers.FilterHTTPRequest(helpers.ActionViewPage, admin.ChangeRole)},
-	{"/admin/ban", chelpers.FilterHTTPRequest(helpers.ActionViewPage, admin.BanPlayer)},
+	{"/admin/roles", chelpers.FilterHTTPRequest(helpers.ActionChangeRole, admin.ChangeRole)},
+	{"/admin/roles/edit", chelpers.FilterHTTPRequest(helpers.ActionChangeRole, admin.ViewRoles)},
+	{"/admin/roles/add", chelpers.FilterHTTPRequest(helpers.ActionChangeRole, admin.AddRole)},
+	{"/admin/roles/parent", chelpers.FilterHTTPRequest(helpers.ActionChangeRole, admin.SetRoleParent)},
+	{"/admin/roles/grant", chelpers.FilterHTTPRequest(helpers.ActionChangeRole, admin.GrantRoleAction)},
+	{"/admin/roles/remove", chelpers.FilterHTTPRequest(helpers.ActionChangeRole, admin.RemoveRole)},
+	{"/admin/ban", chelpers.FilterHTTPRequest(helpers.ActionBanJoin, admin.BanPlayer)},
+	{"/admin/autoban/exempt", chelpers.FilterHTTPRequest(helpers.ActionChangeRole, admin.SetAutoBanExempt)},
 	{"/admin/chatlogs", chelpers.FilterHTTPRequest(helpers.ActionViewLogs, admin.GetChatLogs)},
+	{"/admin/chatlogs/search", chelpers.FilterHTTPRequest(helpers.ActionViewLogs, admin.SearchChat)},
+	{"/admin/chatlogs/context", chelpers.FilterHTTPRequest(helpers.ActionViewLogs, admin.ViewChatContext)},
 	{"/admin/banlogs", chelpers.FilterHTTPRequest(helpers.ActionViewLogs, admin.GetBanLogs)},
+	{"/admin/audit", chelpers.FilterHTTPRequest(helpers.ActionViewLogs, admin.ViewAuditLog)},
+	{"/admin/reports", chelpers.FilterHTTPRequest(helpers.ActionViewLogs, admin.ViewReports)},
+	{"/admin/reports/claim", chelpers.FilterHTTPRequest(helpers.ActionBanJoin, admin.ClaimReport)},
+	{"/admin/reports/resolve", chelpers.FilterHTTPRequest(helpers.ActionBanJoin, admin.ResolveReport)},
+	{"/admin/appeals", chelpers.FilterHTTPRequest(helpers.ActionViewLogs, admin.ViewAppeals)},
+	{"/admin/appeals/view", chelpers.FilterHTTPRequest(helpers.ActionViewLogs, admin.ViewAppeal)},
+	{"/admin/appeals/decide", chelpers.FilterHTTPRequest(helpers.ActionBanJoin, admin.DecideAppeal)},
+	{"/admin/alts", chelpers.FilterHTTPRequest(helpers.ActionViewLogs, admin.ViewAlts)},
+	{"/admin/alts/review", chelpers.FilterHTTPRequest(helpers.ActionBanJoin, admin.ReviewAlt)},
+	{"/admin/chat", chelpers.FilterHTTPRequest(helpers.ActionViewLogs, admin.ViewChatModeration)},
+	{"/admin/chat/filter", chelpers.FilterHTTPRequest(helpers.ActionBanChat, admin.AddChatFilter)},
+	{"/admin/chat/filter/remove", chelpers.FilterHTTPRequest(helpers.ActionBanChat, admin.RemoveChatFilter)},
+	{"/admin/chat/slowmode", chelpers.FilterHTTPRequest(helpers.ActionBanChat, admin.SetSlowMode)},
+	{"/admin/chat/mute", chelpers.FilterHTTPRequest(helpers.ActionBanChat, admin.MuteChat)},
+	{"/admin/directmessages", chelpers.FilterHTTPRequest(helpers.ActionReadDirectMessages, admin.ViewDirectMessages)},
 	{"/admin/server/", chelpers.FilterHTTPRequest(helpers.ModifyServers, admin.ViewServerPage)},
 	{"/admin/server/add", chelpers.FilterHTTPRequest(helpers.ModifyServers, admin.AddServer)},
 	{"/admin/server/remove", chelpers.FilterHTTPRequest(helpers.ModifyServers, admin.RemoveServer)},
+	{"/admin/server/extension", chelpers.FilterHTTPRequest(helpers.ModifyServers, admin.SetMaxExtension)},
 	{"/admin/lobbies", chelpers.FilterHTTPRequest(helpers.ActionViewLogs, admin.ViewOpenLobbies)},
+	{"/admin/lobbies/timeline", chelpers.FilterHTTPRequest(helpers.ActionViewLogs, admin.ViewLobbyTimeline)},
+	{"/admin/demos", chelpers.FilterHTTPRequest(helpers.ActionViewLogs, admin.ViewDemos)},
+	{"/admin/demos/delete", chelpers.FilterHTTPRequest(helpers.ActionDeleteDemos, admin.DeleteDemo)},
+	{"/admin/regions/", chelpers.FilterHTTPRequest(helpers.ActionManageRegions, admin.ViewRegions)},
+	{"/admin/regions/add", chelpers.FilterHTTPRequest(helpers.ActionManageRegions, admin.AddRegion)},
+	{"/admin/regions/override", chelpers.FilterHTTPRequest(helpers.ActionManageRegions, admin.AddRegionOverride)},
+	{"/admin/regions/override/remove", chelpers.FilterHTTPRequest(helpers.ActionManageRegions, admin.RemoveRegionOverride)},
 
 	{"/stats", stats.StatsHandler},
 	{"/badge/", controllers.TwitchBadge},
 	{"/resetMumblePassword", controllers.ResetMumblePassword},
+	{"/demos/list", controllers.DemoList},
+
+	{"/api/v1/lobbies", api.RateLimit(api.Lobbies)},
+	{"/api/v1/lobbies/", api.RateLimit(api.Lobby)},
+	{"/api/v1/players/", api.RateLimit(api.Player)},
+	{"/api/v1/subs", api.RateLimit(api.Substitutes)},
+	{"/api/v1/bans", api.RateLimit(api.Bans)},
+	{"/api/v1/me", api.RateLimit(api.Me)},
 }
 
 func SetupHTTP(mux *http.ServeMux) {
 	for _, route := range routes {
 		mux.HandleFunc(route.pattern, route.handler)
 	}
-	mux.Handle("/demos/", http.StripPrefix("/demos/", http.FileServer(http.Dir(config.Constants.DemosFolder))))
+	if handler := demo.LocalHandler(); handler != nil {
+		mux.Handle("/demos/", handler)
+	}
 
 	if config.Constants.ServeStatic {
 		mux.HandleFunc("/static/", func(w http.ResponseWriter, r *http.Request) {